@@ -3,9 +3,9 @@ package blockchain
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 
 	"github.com/btcsuite/btcd/btcec"
-	"github.com/mr-tron/base58"
 	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 
 	"github.com/tyler-smith/go-bip39"
@@ -34,6 +34,11 @@ type Wallets struct {
 
 //CreateWallets ...
 func CreateWallets(mnemonic, coinType string, count int, isWIF bool) (string, error) {
+	return CreateWalletsWithNetwork(mnemonic, coinType, "mainnet", count, isWIF)
+}
+
+//CreateWalletsWithNetwork create wallets on network: mainnet, testnet, signet or regtest
+func CreateWalletsWithNetwork(mnemonic, coinType, network string, count int, isWIF bool) (string, error) {
 	//0. get mnemonic
 	mnemonic, err := hdwallet.CreateMnemonic(mnemonic)
 	if err != nil {
@@ -43,7 +48,7 @@ func CreateWallets(mnemonic, coinType string, count int, isWIF bool) (string, er
 	result := make([]*WalletObject, 0)
 	for i := 0; i < count; i++ {
 		//1. common address
-		wobj, err := getKeyPair(mnemonic, coinType, i, false, isWIF)
+		wobj, err := getKeyPair(mnemonic, coinType, network, i, false, isWIF)
 		if err != nil {
 			return "", err
 		}
@@ -51,7 +56,7 @@ func CreateWallets(mnemonic, coinType string, count int, isWIF bool) (string, er
 
 		if coinType == "BTC" {
 			//2. segwit address
-			segobj, err := getKeyPair(mnemonic, coinType, i, true, isWIF)
+			segobj, err := getKeyPair(mnemonic, coinType, network, i, true, isWIF)
 			if err != nil {
 				return "", err
 			}
@@ -73,12 +78,17 @@ func CreateWallets(mnemonic, coinType string, count int, isWIF bool) (string, er
 
 //CreateWallet ...
 func CreateWallet(mnemonic, coinType string, isSegwit, isWIF bool) (string, error) {
+	return CreateWalletWithNetwork(mnemonic, coinType, "mainnet", isSegwit, isWIF)
+}
+
+//CreateWalletWithNetwork create wallet on network: mainnet, testnet, signet or regtest
+func CreateWalletWithNetwork(mnemonic, coinType, network string, isSegwit, isWIF bool) (string, error) {
 	mnemonic, err := hdwallet.CreateMnemonic(mnemonic)
 	if err != nil {
 		return "", err
 	}
 
-	obj, err := getKeyPair(mnemonic, coinType, 0, isSegwit, isWIF)
+	obj, err := getKeyPair(mnemonic, coinType, network, 0, isSegwit, isWIF)
 	if err != nil {
 		return "", err
 	}
@@ -91,28 +101,39 @@ func CreateWallet(mnemonic, coinType string, isSegwit, isWIF bool) (string, erro
 	return res, nil
 }
 
-//HexToWIF convert hex to wif
+//HexToWIF convert hex to mainnet wif
 func HexToWIF(hexkey string) string {
-	hexBytes, err := hex.DecodeString(hexkey)
+	return HexToWIFWithNetwork(hexkey, "mainnet")
+}
+
+//HexToWIFWithNetwork convert hex to compressed wif of network: mainnet, testnet, signet or regtest
+func HexToWIFWithNetwork(hexkey, network string) string {
+	return HexToWIFWithCompression(hexkey, network, true)
+}
+
+//HexToWIFWithCompression convert hex to wif of network, uncompressed wif is of old wallets using
+//uncompressed public key
+func HexToWIFWithCompression(hexkey, network string, compressed bool) string {
+	params, err := hdwallet.GetNetParams(network)
 	if err != nil {
 		return ""
 	}
 
-	//0.add version 0x80
-	versionPayload := append([]byte{byte(0x80)}, hexBytes...)
-
-	checksumBytes := hdwallet.CheckSum(versionPayload)
-
-	fullPayload := append(versionPayload, checksumBytes...)
+	if len(hexkey) != 64 {
+		return ""
+	}
 
-	result := base58.Encode(fullPayload)
+	key, err := hdwallet.HexToECDSAPrivateKey(hexkey)
+	if err != nil {
+		return ""
+	}
 
-	return result
+	return hdwallet.EncodeWIF(key, compressed, params)
 }
 
 //getKeyPair ...
-func getKeyPair(mnemonic, coinType string, addressIndex int, isSegwit, isWIF bool) (*WalletObject, error) {
-	wallet, err := hdwallet.NewWallet(mnemonic, coinType)
+func getKeyPair(mnemonic, coinType, network string, addressIndex int, isSegwit, isWIF bool) (*WalletObject, error) {
+	wallet, err := hdwallet.NewWalletWithNetwork(mnemonic, coinType, network)
 	if err != nil {
 		return nil, err
 	}
@@ -161,11 +182,25 @@ func getKeyPair(mnemonic, coinType string, addressIndex int, isSegwit, isWIF boo
 
 //ImportPrivateKey ...
 func ImportPrivateKey(coinType, privateKey string, isSegwit, isWIF bool) (string, error) {
-	//1. Recover private key from string
+	return ImportPrivateKeyWithNetwork(coinType, privateKey, "mainnet", isSegwit, isWIF)
+}
+
+//ImportPrivateKeyWithNetwork import private key on network: mainnet, testnet, signet or regtest
+func ImportPrivateKeyWithNetwork(coinType, privateKey, network string, isSegwit, isWIF bool) (string, error) {
+	params, err := hdwallet.GetNetParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	//1. Recover private key from string, wif of old wallets may be uncompressed
 	var ecdsaPubKey *btcec.PublicKey
-	var err error
+	compressed := true
 	if isWIF {
-		ecdsaPubKey, err = hdwallet.WIFToECDSAPublicKey(privateKey)
+		var key *btcec.PrivateKey
+		key, compressed, err = hdwallet.DecodeWIF(privateKey, params)
+		if err == nil {
+			ecdsaPubKey = key.PubKey()
+		}
 	} else {
 		ecdsaPubKey, err = hdwallet.HexToECDSAPublicKey(privateKey)
 	}
@@ -175,9 +210,21 @@ func ImportPrivateKey(coinType, privateKey string, isSegwit, isWIF bool) (string
 	}
 
 	//2. Generate public key from private key
-	publicKey, address, err := hdwallet.PublicKeyToAddress(coinType, ecdsaPubKey, isSegwit)
-	if err != nil {
-		return "", err
+	var publicKey, address string
+	if !compressed && coinType == "BTC" {
+		//segwit only accepts compressed public key
+		if isSegwit {
+			return "", errors.New("uncompressed wif can not have segwit address")
+		}
+
+		pubkeyBytes := ecdsaPubKey.SerializeUncompressed()
+		publicKey = hex.EncodeToString(pubkeyBytes)
+		address = hdwallet.ToBTCWithParams(pubkeyBytes, false, params)
+	} else {
+		publicKey, address, err = hdwallet.PublicKeyToAddressWithParams(coinType, ecdsaPubKey, isSegwit, params)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	addrTypr := AddresType{
@@ -0,0 +1,392 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/common"
+	mrbase58 "github.com/mr-tron/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+//AddressInfo result of ValidateAddress
+type AddressInfo struct {
+	CoinType string `json:"cointype"`
+	Address  string `json:"address"`
+	Valid    bool   `json:"valid"`
+	//ChecksumValid is also false when address carries no checksum, e.g. lowercase ETH address
+	ChecksumValid bool   `json:"checksumvalid"`
+	Type          string `json:"type"`    //p2pkh, p2sh, p2wpkh, p2wsh, p2tr, hex, eip55, publickey or account
+	Network       string `json:"network"` //network the address belongs to, empty if chain is not encoded
+	Reason        string `json:"reason"`
+}
+
+//ltcNet address prefixes of litecoin network
+type ltcNet struct {
+	name        string
+	pubKeyHash  byte
+	scriptHash  byte
+	scriptHash2 byte //deprecated p2sh prefix shared with bitcoin
+	hrp         string
+}
+
+var btcNetworks = []string{"mainnet", "testnet", "signet", "regtest"}
+
+var ltcNetworks = []ltcNet{
+	{"mainnet", 0x30, 0x32, 0x05, "ltc"},
+	{"testnet", 0x6f, 0x3a, 0xc4, "tltc"},
+	{"regtest", 0x6f, 0x3a, 0xc4, "rltc"},
+}
+
+//eosAccountPattern account name of EOSIO chain
+var eosAccountPattern = regexp.MustCompile(`^[a-z1-5.]{0,11}[a-z1-5]$`)
+
+//ValidateAddress check address of coinType(BTC, LTC, ETH, ETC, EOS or VEX) on network, result is json of AddressInfo
+func ValidateAddress(coinType, address, network string) (string, error) {
+	var info *AddressInfo
+	var err error
+
+	switch coinType {
+	case "BTC":
+		info, err = validateBTCAddress(address, network)
+	case "LTC":
+		info, err = validateLTCAddress(address, network)
+	case "ETH", "ETC":
+		info = validateETHAddress(address)
+	case "EOS", "VEX":
+		info = validateEOSAddress(coinType, address)
+	default:
+		err = fmt.Errorf("coin type %s is not support", coinType)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	info.CoinType = coinType
+	info.Address = address
+
+	strInfo, err := json.Marshal(info)
+	if err != nil {
+		return "", err
+	}
+
+	return string(strInfo), nil
+}
+
+//checkBTCAddress address must be a valid recipient on network of params
+func checkBTCAddress(address string, params *chaincfg.Params) error {
+	if _, err := decodeTaprootAddress(address, params); err == nil {
+		return nil
+	}
+
+	addr, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return fmt.Errorf("invalid address %s: %v", address, err)
+	}
+
+	if !addr.IsForNet(params) {
+		return fmt.Errorf("address %s is not for network %s", address, params.Name)
+	}
+
+	if _, ok := addr.(*btcutil.AddressPubKey); ok {
+		return fmt.Errorf("public key %s is not an address", address)
+	}
+
+	return nil
+}
+
+func isChecksumError(err error) bool {
+	return err == btcutil.ErrChecksumMismatch || err == base58.ErrChecksum ||
+		strings.Contains(err.Error(), "checksum")
+}
+
+//orderNetworks put requested network at first
+func orderNetworks(network string, networks []string) []string {
+	result := []string{network}
+	for _, n := range networks {
+		if n != network {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+func validateBTCAddress(address, network string) (*AddressInfo, error) {
+	if network == "" {
+		network = "mainnet"
+	}
+
+	if _, err := hdwallet.GetNetParams(network); err != nil {
+		return nil, err
+	}
+
+	info := &AddressInfo{
+		ChecksumValid: true,
+	}
+
+	var firstErr error
+	for _, n := range orderNetworks(network, btcNetworks) {
+		params, _ := hdwallet.GetNetParams(n)
+
+		if _, err := decodeTaprootAddress(address, params); err == nil {
+			info.Network = n
+			info.Type = "p2tr"
+			if n != network {
+				info.Reason = fmt.Sprintf("address is for %s", n)
+				return info, nil
+			}
+
+			info.Valid = true
+			return info, nil
+		} else if strings.HasPrefix(strings.ToLower(address), params.Bech32HRPSegwit+"1p") {
+			//witness v1 address of this network, btcutil knows nothing better about it
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		addr, err := btcutil.DecodeAddress(address, params)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if !addr.IsForNet(params) {
+			continue
+		}
+
+		info.Network = n
+
+		switch addr.(type) {
+		case *btcutil.AddressPubKeyHash:
+			info.Type = "p2pkh"
+		case *btcutil.AddressScriptHash:
+			info.Type = "p2sh"
+		case *btcutil.AddressWitnessPubKeyHash:
+			info.Type = "p2wpkh"
+		case *btcutil.AddressWitnessScriptHash:
+			info.Type = "p2wsh"
+		case *btcutil.AddressPubKey:
+			info.Type = "p2pk"
+			info.Reason = "public key is not an address"
+			return info, nil
+		}
+
+		if n != network {
+			info.Reason = fmt.Sprintf("address is for %s", n)
+			return info, nil
+		}
+
+		info.Valid = true
+		return info, nil
+	}
+
+	info.ChecksumValid = firstErr == nil || !isChecksumError(firstErr)
+	if firstErr != nil {
+		info.Reason = firstErr.Error()
+	} else {
+		info.Reason = "unknown address format"
+	}
+
+	return info, nil
+}
+
+func validateLTCAddress(address, network string) (*AddressInfo, error) {
+	if network == "" {
+		network = "mainnet"
+	}
+
+	names := make([]string, 0, len(ltcNetworks))
+	nets := make(map[string]ltcNet)
+	for _, n := range ltcNetworks {
+		names = append(names, n.name)
+		nets[n.name] = n
+	}
+
+	if _, ok := nets[network]; !ok {
+		return nil, fmt.Errorf("network %s is not support", network)
+	}
+
+	info := &AddressInfo{}
+
+	//segwit address
+	lower := strings.ToLower(address)
+	if oneIndex := strings.LastIndex(lower, "1"); oneIndex > 0 {
+		for _, n := range orderNetworks(network, names) {
+			if lower[:oneIndex] != nets[n].hrp {
+				continue
+			}
+
+			hrp, data, err := bech32.Decode(address)
+			if err != nil {
+				info.ChecksumValid = !isChecksumError(err)
+				info.Reason = err.Error()
+				return info, nil
+			}
+			info.ChecksumValid = true
+
+			if len(data) < 1 || data[0] != 0 {
+				info.Reason = "unsupported witness version"
+				return info, nil
+			}
+
+			program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+			if err != nil {
+				info.Reason = err.Error()
+				return info, nil
+			}
+
+			switch len(program) {
+			case 20:
+				info.Type = "p2wpkh"
+			case 32:
+				info.Type = "p2wsh"
+			default:
+				info.Reason = fmt.Sprintf("invalid witness program length %d", len(program))
+				return info, nil
+			}
+
+			info.Network = n
+			if hrp != nets[network].hrp {
+				info.Reason = fmt.Sprintf("address is for %s", n)
+				return info, nil
+			}
+
+			info.Valid = true
+			return info, nil
+		}
+	}
+
+	//base58 address
+	decoded, version, err := base58.CheckDecode(address)
+	if err != nil {
+		info.ChecksumValid = !isChecksumError(err)
+		info.Reason = err.Error()
+		return info, nil
+	}
+	info.ChecksumValid = true
+
+	if len(decoded) != ripemd160.Size {
+		info.Reason = "decoded address is of unknown format"
+		return info, nil
+	}
+
+	for _, n := range orderNetworks(network, names) {
+		switch version {
+		case nets[n].pubKeyHash:
+			info.Type = "p2pkh"
+		case nets[n].scriptHash, nets[n].scriptHash2:
+			info.Type = "p2sh"
+		default:
+			continue
+		}
+
+		info.Network = n
+		if n != network {
+			info.Reason = fmt.Sprintf("address is for %s", n)
+			return info, nil
+		}
+
+		info.Valid = true
+		return info, nil
+	}
+
+	info.Reason = "unknown address type"
+	return info, nil
+}
+
+func validateETHAddress(address string) *AddressInfo {
+	info := &AddressInfo{}
+
+	if !common.IsHexAddress(address) {
+		info.Reason = "address should be 20 bytes hex string"
+		return info
+	}
+
+	hexAddr := address
+	if strings.HasPrefix(hexAddr, "0x") || strings.HasPrefix(hexAddr, "0X") {
+		hexAddr = hexAddr[2:]
+	}
+
+	//EIP-55 checksum is only carried by mixed case address
+	if hexAddr == strings.ToLower(hexAddr) || hexAddr == strings.ToUpper(hexAddr) {
+		info.Type = "hex"
+		info.Valid = true
+		return info
+	}
+
+	info.Type = "eip55"
+	info.ChecksumValid = common.HexToAddress(address).Hex()[2:] == hexAddr
+	info.Valid = info.ChecksumValid
+	if !info.Valid {
+		info.Reason = "invalid EIP-55 checksum"
+	}
+
+	return info
+}
+
+func validateEOSAddress(coinType, address string) *AddressInfo {
+	info := &AddressInfo{}
+
+	if eosAccountPattern.MatchString(address) {
+		info.Type = "account"
+		info.Valid = true
+		return info
+	}
+
+	var payload string
+	var suffix []byte
+	switch {
+	case strings.HasPrefix(address, "PUB_K1_"):
+		payload = address[len("PUB_K1_"):]
+		suffix = []byte("K1")
+	case strings.HasPrefix(address, coinType):
+		payload = address[len(coinType):]
+	default:
+		info.Reason = fmt.Sprintf("address should be account name or public key with prefix %s or PUB_K1_", coinType)
+		return info
+	}
+
+	decoded, err := mrbase58.Decode(payload)
+	if err != nil || len(decoded) != 37 {
+		info.Reason = "public key should be 33 bytes with 4 bytes checksum"
+		return info
+	}
+
+	pubKey := decoded[:33]
+
+	ripemder := ripemd160.New()
+	ripemder.Write(pubKey)
+	ripemder.Write(suffix)
+	checksum := ripemder.Sum(nil)[:4]
+
+	info.ChecksumValid = bytes.Equal(checksum, decoded[33:])
+	if !info.ChecksumValid {
+		info.Reason = "invalid public key checksum"
+		return info
+	}
+
+	if _, err := btcec.ParsePubKey(pubKey, btcec.S256()); err != nil {
+		info.Reason = fmt.Sprintf("invalid public key: %v", err)
+		return info
+	}
+
+	info.Type = "publickey"
+	info.Valid = true
+	return info
+}
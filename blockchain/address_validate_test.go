@@ -0,0 +1,57 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	cases := []struct {
+		coinType string
+		address  string
+		network  string
+		valid    bool
+		checksum bool
+		addrType string
+	}{
+		{"BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "mainnet", true, true, "p2pkh"},
+		{"BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", "mainnet", false, false, ""},
+		{"BTC", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "mainnet", true, true, "p2sh"},
+		{"BTC", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "mainnet", true, true, "p2wpkh"},
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "mainnet", true, true, "p2tr"},
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj2", "mainnet", false, false, ""},
+		{"BTC", "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "mainnet", false, true, "p2tr"},
+		{"BTC", "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "testnet", true, true, "p2tr"},
+		{"BTC", "mrj2K6txjo2QBcSmuAzHj4nD1oXSEJE1Qo", "mainnet", false, true, "p2pkh"},
+		{"BTC", "mrj2K6txjo2QBcSmuAzHj4nD1oXSEJE1Qo", "testnet", true, true, "p2pkh"},
+		{"BTC", "", "mainnet", false, true, ""},
+		{"LTC", "LKDyUEtTR1HXamkiEphisSiBJu6o3ZPE34", "mainnet", true, true, "p2pkh"},
+		{"LTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "mainnet", false, true, ""},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "", true, true, "eip55"},
+		{"ETH", "0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "", false, false, "eip55"},
+		{"ETH", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "", true, false, "hex"},
+		{"VEX", "VEX5awQ9H9Tj2qorH1ETLDDynvdQgxxFZepfr1iS5EU3WtWvzyfe1", "", true, true, "publickey"},
+		{"VEX", "VEX5awQ9H9Tj2qorH1ETLDDynvdQgxxFZepfr1iS5EU3WtWvzyfe2", "", false, false, ""},
+		{"VEX", "atokentry123", "", true, false, "account"},
+		{"EOS", "VEX5awQ9H9Tj2qorH1ETLDDynvdQgxxFZepfr1iS5EU3WtWvzyfe1", "", false, false, ""},
+	}
+
+	for _, c := range cases {
+		res, err := ValidateAddress(c.coinType, c.address, c.network)
+		if err != nil {
+			t.Errorf("ValidateAddress %s %s: %v\n", c.coinType, c.address, err)
+			continue
+		}
+
+		var info AddressInfo
+		json.Unmarshal([]byte(res), &info)
+
+		if info.Valid != c.valid || info.ChecksumValid != c.checksum || info.Type != c.addrType {
+			t.Errorf("ValidateAddress %s %s on %s: %v\n", c.coinType, c.address, c.network, res)
+		}
+	}
+
+	if _, err := ValidateAddress("XRP", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", ""); err == nil {
+		t.Errorf("ValidateAddress should fail on unsupported coin\n")
+	}
+}
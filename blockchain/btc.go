@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 
@@ -20,68 +23,41 @@ const (
 	// CurrentTxInSequenceNum is MaxTxInSequenceNum -2
 	CurrentTxInSequenceNum uint32 = 0xfffffffd
 
+	//MinDustOutput dust threshold of P2PKH output at DefaultDustRelayFee
 	MinDustOutput int64 = 546
+
+	//DefaultDustRelayFee dust relay fee of Bitcoin Core in satoshis per 1000 virtual bytes
+	DefaultDustRelayFee int64 = 3000
 )
 
-//GetAddressFromPrivKey get address from privatekey
-func getAddressFromPrivKey(prikey *btcec.PrivateKey, isWitSeg bool) string {
-	priKey := btcec.PrivateKey(*prikey)
-	esdsaPubKey := priKey.PubKey()
-	pubkeyBytes := esdsaPubKey.SerializeCompressed()
+//GetPayToAddrScript output script paying to address, OP_1 <output key> for P2TR
+func getPayToAddrScript(address string, params *chaincfg.Params) ([]byte, error) {
+	if program, err := decodeTaprootAddress(address, params); err == nil {
+		return (&taprootAddress{program: program, params: params}).pkScript()
+	}
 
-	var address string
-	if isWitSeg {
-		address = hdwallet.ToBTC(pubkeyBytes, true)
-	} else {
-		address = hdwallet.ToBTC(pubkeyBytes, false)
+	rcvAddress, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %v", address, err)
 	}
 
-	return address
-}
+	rcvScript, err := txscript.PayToAddrScript(rcvAddress)
+	if err != nil {
+		return nil, fmt.Errorf("pay to address %s: %v", address, err)
+	}
 
-//GetPayToAddrScript add script
-func getPayToAddrScript(address string) []byte {
-	rcvAddress, _ := btcutil.DecodeAddress(address, &chaincfg.MainNetParams)
-	rcvScript, _ := txscript.PayToAddrScript(rcvAddress)
-	return rcvScript
+	return rcvScript, nil
 }
 
-func getTxOut(address string, amount int64) *wire.TxOut {
+func getTxOut(address string, amount int64, params *chaincfg.Params) (*wire.TxOut, error) {
 	// create TxOut
-	rcvscript := getPayToAddrScript(address)
+	rcvscript, err := getPayToAddrScript(address, params)
+	if err != nil {
+		return nil, err
+	}
 
 	txOut := wire.NewTxOut(amount, rcvscript)
-	return txOut
-}
-
-func createOmniData(currencyID, amount int64) string {
-	omniPrefix := "6f6d6e69" //omni ascii
-	txVersion := "0000"      //transaction version
-	txType := "0000"         //transaction type: simple send
-
-	curID := fmt.Sprintf("%08x", currencyID) //currency id
-	amt := fmt.Sprintf("%016x", amount)      //amount
-
-	result := omniPrefix + txVersion + txType + curID + amt
-
-	return result
-}
-
-func getOmniTxOut(currencyID, amount int64) *wire.TxOut {
-	omniData := createOmniData(currencyID, amount)
-
-	data, _ := hex.DecodeString(omniData)
-
-	b := txscript.NewScriptBuilder()
-	b.AddOp(txscript.OP_RETURN)
-	b.AddData([]byte(data))
-
-	sigscript, _ := b.Script()
-
-	// fmt.Printf("opreturn: %v\n", hex.EncodeToString(sigscript))
-
-	txOut := wire.NewTxOut(0, sigscript)
-	return txOut
+	return txOut, nil
 }
 
 func txToHex(tx *wire.MsgTx) string {
@@ -90,165 +66,644 @@ func txToHex(tx *wire.MsgTx) string {
 	return hex.EncodeToString(buf.Bytes())
 }
 
-//IsWitSehAddress check address type
-func isWitSehAddress(addr string) bool {
-	rcvAddress, _ := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
-
-	switch rcvAddress.(type) {
-	case *btcutil.AddressWitnessPubKeyHash:
-		return true
-	case *btcutil.AddressWitnessScriptHash:
-		return true
-	case *btcutil.AddressScriptHash:
-		return true
-	default:
-		return false
+//buildBTCTx construct btc transaction, also return the change dropped to fee for being dust
+func buildBTCTx(input BTCTxInput, signer hdwallet.Signer) (*wire.MsgTx, int64, error) {
+	params, err := hdwallet.GetNetParams(input.Network)
+	if err != nil {
+		return nil, 0, err
 	}
-}
 
-//buildBTCTx construct btc transaction
-func buildBTCTx(input BTCTxInput) (*wire.MsgTx, error) {
+	if input.isOmni() {
+		if err := input.setOmniDust(params); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := input.applyPrivacy(params); err != nil {
+		return nil, 0, err
+	}
+
+	if err := input.checkAddresses(params); err != nil {
+		return nil, 0, err
+	}
+
+	//standard transaction carries only one OP_RETURN output
+	if input.Data != nil && input.isOmni() {
+		return nil, 0, errors.New("omni transaction can not carry another data output")
+	}
 
 	//0. create new empty transaction
-	redemTx := wire.NewMsgTx(wire.TxVersion)
+	redemTx := newBTCTx(input.Utxos, input.LockTime)
 
-	//1. calculate change of btc
-	changeAmount := input.getChangeAmount()
-	if changeAmount > 0 {
-		if changeAmount > MinDustOutput {
-			//change
-			redemTx.AddTxOut(getTxOut(input.ChangeAddress, changeAmount))
-		} else {
-			//skip change ,make it miner fee
-		}
+	//1. calculate change of btc, change below dust threshold is left to miner
+	changeAmount, droppedChange, err := input.splitChange(params)
+	if err != nil {
+		return nil, 0, err
+	}
 
+	//SIGHASH_SINGLE input i signs output i, so recipients keep the index of their input
+	//and change goes last
+	single, err := input.hasSigHashSingle()
+	if err != nil {
+		return nil, 0, err
+	}
+	changeLast := single && !input.isOmni()
+
+	if changeAmount > 0 && !changeLast {
+		if err := addTxOut(redemTx, input.ChangeAddress, changeAmount, params); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	//2.construct vout
-	if input.OmniCurrencyID != 0 {
+	if input.isOmni() {
+		payload := input.getOmniPayload()
+		omniOut, err := getOmniTxOut(payload)
+		if err != nil {
+			return nil, 0, err
+		}
+
 		if input.NeedOmniOut == 1 {
-			redemTx.AddTxOut(getTxOut(input.ChangeAddress, input.Dust))
+			if err := addTxOut(redemTx, input.ChangeAddress, input.Dust, params); err != nil {
+				return nil, 0, err
+			}
 		}
 
 		//add omni txout
-		redemTx.AddTxOut(getOmniTxOut(input.OmniCurrencyID, input.OmniAmount))
-
-		for _, v := range input.To {
-			redemTx.AddTxOut(getTxOut(v.To, input.Dust))
+		redemTx.AddTxOut(omniOut)
+
+		//omni takes the last output as reference of the recipient
+		if payload.hasReference() {
+			if input.OmniAddress != "" {
+				if err := addTxOut(redemTx, input.OmniAddress, input.Dust, params); err != nil {
+					return nil, 0, err
+				}
+			} else {
+				for _, v := range input.To {
+					if err := addTxOut(redemTx, v.To, input.Dust, params); err != nil {
+						return nil, 0, err
+					}
+				}
+			}
 		}
 	} else {
 		for _, v := range input.To {
-			redemTx.AddTxOut(getTxOut(v.To, v.Satoshis))
+			dust, err := input.dustThreshold(v.To, params)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if v.Satoshis < dust {
+				return nil, 0, fmt.Errorf("output of %d satoshis to %s is below dust threshold %d", v.Satoshis, v.To, dust)
+			}
+
+			if err := addTxOut(redemTx, v.To, v.Satoshis, params); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		if input.Data != nil {
+			dataOut, err := getDataTxOut(input.Data)
+			if err != nil {
+				return nil, 0, err
+			}
+			redemTx.AddTxOut(dataOut)
 		}
+
+		if changeAmount > 0 && changeLast {
+			if err := addTxOut(redemTx, input.ChangeAddress, changeAmount, params); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if err := orderOutputs(redemTx, input.OutputOrder); err != nil {
+		return nil, 0, err
+	}
+
+	//3. vins with signatures
+	if err := signBTCTx(redemTx, input.Utxos, signer, params); err != nil {
+		return nil, 0, err
 	}
 
-	//3. vins
-	for _, txin := range input.Utxos {
+	return redemTx, droppedChange, nil
+}
+
+//newBTCTx create empty transaction, relative timelock(BIP-68) needs version 2
+func newBTCTx(utxos []Utxo, lockTime uint32) *wire.MsgTx {
+	txVersion := int32(wire.TxVersion)
+	if hasSequence(utxos) {
+		txVersion = 2
+	}
+
+	redemTx := wire.NewMsgTx(txVersion)
+	redemTx.LockTime = lockTime
+
+	return redemTx
+}
+
+//signBTCTx add utxos as inputs of tx, then sign and verify every input,
+//utxos without private key are signed by signer
+func signBTCTx(redemTx *wire.MsgTx, utxos []Utxo, signer hdwallet.Signer, params *chaincfg.Params) error {
+	//vins
+	for _, txin := range utxos {
 		hash, err := chainhash.NewHashFromStr(txin.TxID)
 		if err != nil {
-			return nil, fmt.Errorf("could not get hash from transaction ID: %v", err)
+			return fmt.Errorf("could not get hash from transaction ID: %v", err)
 		}
 
 		// create TxIn
 		outPoint := wire.NewOutPoint(hash, uint32(txin.OutputIndex))
 		txIn := wire.NewTxIn(outPoint, nil, nil)
 		txIn.Sequence = CurrentTxInSequenceNum
+		if txin.Sequence != nil {
+			txIn.Sequence = *txin.Sequence
+		}
 		redemTx.AddTxIn(txIn)
 	}
 
+	//spent outputs, checked against utxo address
+	prevOuts := make([]*wire.TxOut, 0, len(utxos))
+	for i := range utxos {
+		prevOut, err := getPrevOut(&utxos[i], params)
+		if err != nil {
+			return newInputError(redemTx, i, err)
+		}
+
+		prevOuts = append(prevOuts, prevOut)
+	}
+
 	//filled tx.vin.scriptsig
-	for i := range input.Utxos {
-		// sign transaction
-		pkScript, err := hex.DecodeString(input.Utxos[i].PkScript)
+	txSigHashes := txscript.NewTxSigHashes(redemTx)
+	for i := range utxos {
+		inSigner, keyID, err := utxoSigner(&utxos[i], signer, params)
 		if err != nil {
-			return nil, fmt.Errorf("could not get pkscript: %v", err)
+			return newInputError(redemTx, i, err)
 		}
 
-		myPrivateKey, err := hdwallet.HexToECDSAPrivateKey(input.Utxos[i].Private)
+		err = signInput(redemTx, i, &utxos[i], prevOuts[i], inSigner, keyID, txSigHashes, params)
 		if err != nil {
-			return nil, err
+			return newInputError(redemTx, i, err)
 		}
+	}
 
-		if isWitSehAddress(input.Utxos[i].Address) {
-			txSigHashes := txscript.NewTxSigHashes(redemTx)
+	//validate every signature before the transaction leaves
+	return verifyBTCTx(redemTx, prevOuts)
+}
 
-			witnessTx, err := txscript.WitnessSignature(
-				redemTx, // The tx to be signed.
-				txSigHashes,
-				i, // The index of the txin the signature is for.
-				input.Utxos[i].Satoshis,
-				pkScript,            // The other half of the script from the PubKeyHash.
-				txscript.SigHashAll, // The signature flags that indicate what the sig covers.
-				myPrivateKey,        // The key to generate the signature with.
-				true)                // The compress sig flag. This saves space on the blockchain.
+//sigHashMask mask of base sighash type without ANYONECANPAY
+const sigHashMask = 0x1f
 
-			if err != nil {
-				return nil, fmt.Errorf("could not generate signature: %v", err)
-			}
+//getSigHashType parse sighash type of Utxo: ALL(default), NONE or SINGLE, each may end with |ANYONECANPAY
+func getSigHashType(sigHash string) (txscript.SigHashType, error) {
+	sigHash = strings.ToUpper(strings.Replace(sigHash, " ", "", -1))
+	sigHash = strings.TrimPrefix(sigHash, "SIGHASH_")
 
-			redemTx.TxIn[i].Witness = witnessTx
+	hashType := txscript.SigHashType(0)
+	if strings.HasSuffix(sigHash, "|ANYONECANPAY") {
+		hashType = txscript.SigHashAnyOneCanPay
+		sigHash = strings.TrimSuffix(sigHash, "|ANYONECANPAY")
+	}
 
-			for _, wit := range redemTx.TxIn[i].Witness {
-				fmt.Printf("wit: %v\n", hex.EncodeToString(wit))
-			}
+	switch sigHash {
+	case "", "ALL":
+		hashType |= txscript.SigHashAll
+	case "NONE":
+		hashType |= txscript.SigHashNone
+	case "SINGLE":
+		hashType |= txscript.SigHashSingle
+	default:
+		return 0, fmt.Errorf("sighash type %s is not support", sigHash)
+	}
 
-			//scriptSig
-			pk := (*btcec.PublicKey)(&myPrivateKey.PublicKey)
+	return hashType, nil
+}
 
-			pkData := pk.SerializeCompressed()
+//hasSigHashSingle any utxo signs with SIGHASH_SINGLE
+func (input BTCTxInput) hasSigHashSingle() (bool, error) {
+	for _, utxo := range input.Utxos {
+		hashType, err := getSigHashType(utxo.SigHash)
+		if err != nil {
+			return false, err
+		}
 
-			address, err := btcutil.NewAddressWitnessPubKeyHash(
-				btcutil.Hash160(pkData), &chaincfg.MainNetParams)
-			if err != nil {
-				return nil, err
-			}
+		if hashType&sigHashMask == txscript.SigHashSingle {
+			return true, nil
+		}
+	}
 
-			scriptsig, err := txscript.PayToAddrScript(address)
-			if err != nil {
-				return nil, err
-			}
+	return false, nil
+}
 
-			buf := bytes.NewBuffer(make([]byte, 0, len(scriptsig)+2))
-			buf.WriteByte(byte(len(scriptsig)))
-			buf.Write(scriptsig)
+//parsePrivateKey private key of 64 hex chars or WIF of network
+func parsePrivateKey(privateKey string, params *chaincfg.Params) (*btcec.PrivateKey, error) {
+	if len(privateKey) == 64 {
+		if _, err := hex.DecodeString(privateKey); err == nil {
+			return hdwallet.HexToECDSAPrivateKey(privateKey)
+		}
+	}
 
-			// sigStr := hex.EncodeToString(buf.Bytes())
-			// fmt.Printf("i => sig:   %v %v\n", i, sigStr)
+	key, _, err := hdwallet.DecodeWIF(privateKey, params)
+	if err != nil {
+		return nil, fmt.Errorf("private key is neither hex nor WIF: %v", err)
+	}
 
-			redemTx.TxIn[i].SignatureScript = buf.Bytes()
+	return key, nil
+}
 
-		} else {
-			scriptsig, err := txscript.SignatureScript(
-				redemTx,             // The tx to be signed.
-				i,                   // The index of the txin the signature is for.
-				pkScript,            // The other half of the script from the PubKeyHash.
-				txscript.SigHashAll, // The signature flags that indicate what the sig covers.
-				myPrivateKey,        // The key to generate the signature with.
-				true)                // The compress sig flag. This saves space on the blockchain.
+//utxoSigner private key of utxo signs in memory, otherwise signer signs with Utxo.KeyID
+func utxoSigner(utxo *Utxo, signer hdwallet.Signer, params *chaincfg.Params) (hdwallet.Signer, string, error) {
+	if utxo.Private != "" {
+		key, err := parsePrivateKey(utxo.Private, params)
+		if err != nil {
+			return nil, "", err
+		}
 
-			if err != nil {
-				return nil, fmt.Errorf("could not generate signature: %v", err)
-			}
+		keySigner := hdwallet.NewKeySigner()
+		keySigner.AddKey("", key)
 
-			// sigStr := hex.EncodeToString(scriptsig)
-			// fmt.Printf("i => sig:   %v %v\n", i, sigStr)
+		return keySigner, "", nil
+	}
 
-			redemTx.TxIn[i].SignatureScript = scriptsig
+	if signer == nil {
+		return nil, "", errors.New("utxo has neither private key nor signer")
+	}
 
-			//Validate signature
-			vm, err := txscript.NewEngine(pkScript, redemTx, i, txscript.StandardVerifyFlags, nil, nil, input.Utxos[i].Satoshis)
-			if err != nil {
-				return nil, fmt.Errorf("validate signature: %v", err)
-			}
+	return signer, utxo.KeyID, nil
+}
+
+//signDigest sign digest with signer, result is DER signature with hashType
+func signDigest(digest []byte, hashType txscript.SigHashType, signer hdwallet.Signer, keyID string) ([]byte, error) {
+	sig, err := signer.SignDigest(keyID, digest)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate signature: %v", err)
+	}
+
+	der, err := hdwallet.DERSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(der, byte(hashType)), nil
+}
+
+//rawTxInSignature legacy signature of input i over subScript
+func rawTxInSignature(tx *wire.MsgTx, i int, subScript []byte, hashType txscript.SigHashType,
+	signer hdwallet.Signer, keyID string) ([]byte, error) {
+	digest, err := txscript.CalcSignatureHash(subScript, hashType, tx, i)
+	if err != nil {
+		return nil, err
+	}
+
+	return signDigest(digest, hashType, signer, keyID)
+}
+
+//rawTxInWitnessSignature BIP-143 signature of input i over subScript
+func rawTxInWitnessSignature(tx *wire.MsgTx, txSigHashes *txscript.TxSigHashes, i int, amount int64,
+	subScript []byte, hashType txscript.SigHashType, signer hdwallet.Signer, keyID string) ([]byte, error) {
+	digest, err := txscript.CalcWitnessSigHash(subScript, txSigHashes, hashType, tx, i, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return signDigest(digest, hashType, signer, keyID)
+}
+
+//getDustThreshold dust limit of output script by GetDustThreshold of Bitcoin Core at dustRelayFee satoshis/kvB,
+//at default fee it is 546 for P2PKH, 540 for P2SH, 294 for P2WPKH, 330 for P2WSH and P2TR
+func getDustThreshold(pkScript []byte, dustRelayFee int64) int64 {
+	if txscript.GetScriptClass(pkScript) == txscript.NullDataTy {
+		return 0
+	}
+
+	if dustRelayFee <= 0 {
+		dustRelayFee = DefaultDustRelayFee
+	}
+
+	//output with the input spending it: outpoint, script length, signature and public key, sequence,
+	//witness is discounted by scale factor 4
+	size := int64(wire.NewTxOut(0, pkScript).SerializeSize())
+	if txscript.IsWitnessProgram(pkScript) {
+		size += 32 + 4 + 1 + 107/4 + 4
+	} else {
+		size += 32 + 4 + 1 + 107 + 4
+	}
+
+	return size * dustRelayFee / 1000
+}
+
+//GetDustThreshold dust limit in satoshis of output to address, dustRelayFee is satoshis/kvB, 0 means DefaultDustRelayFee
+func GetDustThreshold(address, network string, dustRelayFee int64) (int64, error) {
+	params, err := hdwallet.GetNetParams(network)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := checkBTCAddress(address, params); err != nil {
+		return 0, err
+	}
+
+	return addressDustThreshold(address, dustRelayFee, params)
+}
+
+//addressDustThreshold dust limit of output to address at dustRelayFee satoshis/kvB
+func addressDustThreshold(address string, dustRelayFee int64, params *chaincfg.Params) (int64, error) {
+	pkScript, err := getPayToAddrScript(address, params)
+	if err != nil {
+		return 0, err
+	}
+
+	return getDustThreshold(pkScript, dustRelayFee), nil
+}
 
-			if err := vm.Execute(); err != nil {
-				return nil, fmt.Errorf("vm.Execute: %v", err)
+func (input BTCTxInput) dustThreshold(address string, params *chaincfg.Params) (int64, error) {
+	return addressDustThreshold(address, input.DustRelayFee, params)
+}
+
+//splitChange change kept as output, and change dropped to fee for being below dust threshold,
+//utxos must pay outputs and fee
+func (input BTCTxInput) splitChange(params *chaincfg.Params) (int64, int64, error) {
+	if input.Fee < 0 {
+		return 0, 0, fmt.Errorf("fee %d should not be negative", input.Fee)
+	}
+
+	changeAmount := input.getChangeAmount()
+	if changeAmount < 0 {
+		return 0, 0, fmt.Errorf("insufficient funds, utxos need %d more satoshis to pay outputs and fee", -changeAmount)
+	}
+
+	if changeAmount == 0 {
+		return 0, 0, nil
+	}
+
+	dust, err := input.dustThreshold(input.ChangeAddress, params)
+	if err != nil {
+		return 0, 0, fmt.Errorf("change address: %v", err)
+	}
+
+	if changeAmount < dust {
+		return 0, changeAmount, nil
+	}
+
+	return changeAmount, 0, nil
+}
+
+//setOmniDust Dust is the amount of every omni dust output, the highest threshold of them by default
+func (input *BTCTxInput) setOmniDust(params *chaincfg.Params) error {
+	addresses := make([]string, 0)
+	if input.NeedOmniOut == 1 {
+		addresses = append(addresses, input.ChangeAddress)
+	}
+
+	if input.getOmniPayload().hasReference() {
+		if input.OmniAddress != "" {
+			addresses = append(addresses, input.OmniAddress)
+		} else {
+			for _, v := range input.To {
+				addresses = append(addresses, v.To)
 			}
 		}
+	}
 
+	maxDust := int64(0)
+	for _, address := range addresses {
+		dust, err := input.dustThreshold(address, params)
+		if err != nil {
+			return err
+		}
+
+		if input.Dust != 0 && input.Dust < dust {
+			return fmt.Errorf("omni dust %d is below dust threshold %d of %s", input.Dust, dust, address)
+		}
+
+		if dust > maxDust {
+			maxDust = dust
+		}
+	}
+
+	if input.Dust == 0 {
+		input.Dust = maxDust
 	}
 
-	return redemTx, nil
+	return nil
+}
+
+//checkAddresses recipients and change address must be valid on network
+func (input BTCTxInput) checkAddresses(params *chaincfg.Params) error {
+	for _, v := range input.To {
+		if err := checkBTCAddress(v.To, params); err != nil {
+			return err
+		}
+	}
+
+	if input.OmniAddress != "" {
+		if err := checkBTCAddress(input.OmniAddress, params); err != nil {
+			return fmt.Errorf("omni address: %v", err)
+		}
+	}
+
+	changeAmount, _, err := input.splitChange(params)
+	if err != nil {
+		return err
+	}
+
+	if input.ChangeAddress != "" || changeAmount > 0 || input.NeedOmniOut == 1 {
+		if err := checkBTCAddress(input.ChangeAddress, params); err != nil {
+			return fmt.Errorf("change address: %v", err)
+		}
+	}
+
+	return nil
+}
+
+//getDataTxOut OP_RETURN output of data, payload over 80 bytes is not standard
+func getDataTxOut(d *DataOutput) (*wire.TxOut, error) {
+	var data []byte
+	switch {
+	case d.Hex != "" && d.Text != "":
+		return nil, errors.New("data output should be either hex or text")
+	case d.Hex != "":
+		raw, err := hex.DecodeString(d.Hex)
+		if err != nil {
+			return nil, fmt.Errorf("data output hex: %v", err)
+		}
+		data = raw
+	case d.Text != "":
+		if !utf8.ValidString(d.Text) {
+			return nil, errors.New("data output text should be UTF-8")
+		}
+		data = []byte(d.Text)
+	default:
+		return nil, errors.New("data output is empty")
+	}
+
+	if len(data) > txscript.MaxDataCarrierSize {
+		return nil, fmt.Errorf("data output of %d bytes exceeds %d bytes", len(data), txscript.MaxDataCarrierSize)
+	}
+
+	pkScript, err := txscript.NullDataScript(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(0, pkScript), nil
+}
+
+//getPrevOut get the output spent by utxo
+func getPrevOut(utxo *Utxo, params *chaincfg.Params) (*wire.TxOut, error) {
+	pkScript, err := hex.DecodeString(utxo.PkScript)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pkscript: %v", err)
+	}
+
+	if utxo.Address == "" {
+		return wire.NewTxOut(utxo.Satoshis, pkScript), nil
+	}
+
+	address, err := btcutil.DecodeAddress(utxo.Address, params)
+	if err != nil {
+		return nil, fmt.Errorf("decode address %s: %v", utxo.Address, err)
+	}
+
+	addrScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	//the witness program is accepted as pkscript of p2sh-p2wpkh address
+	isNestedProgram := txscript.IsPayToWitnessPubKeyHash(pkScript) &&
+		bytes.Equal(btcutil.Hash160(pkScript), address.ScriptAddress())
+
+	if !bytes.Equal(addrScript, pkScript) && !isNestedProgram {
+		return nil, fmt.Errorf("pkscript %s does not pay to address %s", utxo.PkScript, utxo.Address)
+	}
+
+	return wire.NewTxOut(utxo.Satoshis, addrScript), nil
+}
+
+//signInput fill scriptsig or witness of input i
+func signInput(tx *wire.MsgTx, i int, utxo *Utxo, prevOut *wire.TxOut, signer hdwallet.Signer, keyID string,
+	txSigHashes *txscript.TxSigHashes, params *chaincfg.Params) error {
+	pkData, err := signer.PublicKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	hashType, err := getSigHashType(utxo.SigHash)
+	if err != nil {
+		return err
+	}
+
+	//without output of the same index SIGHASH_SINGLE signs the constant 1
+	if hashType&sigHashMask == txscript.SigHashSingle && i >= len(tx.TxOut) {
+		return fmt.Errorf("SIGHASH_SINGLE needs output %d", i)
+	}
+
+	if utxo.Script != "" {
+		return signScriptInput(tx, i, utxo, prevOut, signer, keyID, pkData, hashType, txSigHashes)
+	}
+
+	switch txscript.GetScriptClass(prevOut.PkScript) {
+	case txscript.WitnessV0PubKeyHashTy:
+		sig, err := rawTxInWitnessSignature(tx, txSigHashes, i, prevOut.Value,
+			prevOut.PkScript, hashType, signer, keyID)
+		if err != nil {
+			return err
+		}
+
+		tx.TxIn[i].Witness = wire.TxWitness{sig, pkData}
+
+	case txscript.ScriptHashTy:
+		//p2sh-p2wpkh, redeem script is the witness program of key
+		address, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), params)
+		if err != nil {
+			return err
+		}
+
+		program, err := txscript.PayToAddrScript(address)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(prevOut.PkScript[2:22], btcutil.Hash160(program)) {
+			return errors.New("pkscript is not p2sh-p2wpkh of private key")
+		}
+
+		sig, err := rawTxInWitnessSignature(tx, txSigHashes, i, prevOut.Value,
+			program, hashType, signer, keyID)
+		if err != nil {
+			return err
+		}
+
+		scriptsig, err := txscript.NewScriptBuilder().AddData(program).Script()
+		if err != nil {
+			return err
+		}
+
+		tx.TxIn[i].Witness = wire.TxWitness{sig, pkData}
+		tx.TxIn[i].SignatureScript = scriptsig
+
+	default:
+		//p2pkh of uncompressed key, e.g. an old paper wallet
+		if uncompressed, ok := uncompressedPubKey(prevOut, pkData); ok {
+			pkData = uncompressed
+		}
+
+		sig, err := rawTxInSignature(tx, i, prevOut.PkScript, hashType, signer, keyID)
+		if err != nil {
+			return err
+		}
+
+		scriptsig, err := txscript.NewScriptBuilder().AddData(sig).AddData(pkData).Script()
+		if err != nil {
+			return err
+		}
+
+		tx.TxIn[i].SignatureScript = scriptsig
+	}
+
+	return nil
+}
+
+//uncompressedPubKey uncompressed form of compressed pkData when prevOut is p2pkh of it
+func uncompressedPubKey(prevOut *wire.TxOut, pkData []byte) ([]byte, bool) {
+	if txscript.GetScriptClass(prevOut.PkScript) != txscript.PubKeyHashTy {
+		return nil, false
+	}
+
+	pubKey, err := btcec.ParsePubKey(pkData, btcec.S256())
+	if err != nil {
+		return nil, false
+	}
+
+	uncompressed := pubKey.SerializeUncompressed()
+	if !bytes.Equal(prevOut.PkScript[3:23], btcutil.Hash160(uncompressed)) {
+		return nil, false
+	}
+
+	return uncompressed, true
+}
+
+//verifyBTCTx run script engine on every input with its spent output
+func verifyBTCTx(tx *wire.MsgTx, prevOuts []*wire.TxOut) error {
+	if len(prevOuts) != len(tx.TxIn) {
+		return fmt.Errorf("transaction has %d inputs but %d spent outputs", len(tx.TxIn), len(prevOuts))
+	}
+
+	txSigHashes := txscript.NewTxSigHashes(tx)
+	for i, prevOut := range prevOuts {
+		vm, err := txscript.NewEngine(prevOut.PkScript, tx, i, txscript.StandardVerifyFlags,
+			nil, txSigHashes, prevOut.Value)
+		if err != nil {
+			return newInputError(tx, i, fmt.Errorf("validate signature: %v", err))
+		}
+
+		if err := vm.Execute(); err != nil {
+			return newInputError(tx, i, fmt.Errorf("vm.Execute: %v", err))
+		}
+	}
+
+	return nil
 }
 
 //TransferBTC make btc transaction
@@ -259,7 +714,7 @@ func TransferBTC(btc string) (*TransactionBTC, error) {
 		return nil, err
 	}
 
-	tx, err := buildBTCTx(input)
+	tx, droppedChange, err := buildBTCTx(input, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -268,7 +723,39 @@ func TransferBTC(btc string) (*TransactionBTC, error) {
 	txid := tx.TxHash().String()
 
 	return &TransactionBTC{
-		HexTx: hexTx,
-		TxID:  txid,
+		HexTx:         hexTx,
+		TxID:          txid,
+		DroppedChange: droppedChange,
 	}, nil
 }
+
+//TransferBTCWithSigner make btc transaction, utxos without private key are signed by signer with Utxo.KeyID
+func TransferBTCWithSigner(btc string, signer hdwallet.Signer) (*TransactionBTC, error) {
+	var input BTCTxInput
+	err := json.Unmarshal([]byte(btc), &input)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, droppedChange, err := buildBTCTx(input, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionBTC{
+		HexTx:         txToHex(tx),
+		TxID:          tx.TxHash().String(),
+		DroppedChange: droppedChange,
+	}, nil
+}
+
+//addTxOut add output of amount paying to address
+func addTxOut(tx *wire.MsgTx, address string, amount int64, params *chaincfg.Params) error {
+	txOut, err := getTxOut(address, amount, params)
+	if err != nil {
+		return err
+	}
+
+	tx.AddTxOut(txOut)
+	return nil
+}
@@ -0,0 +1,330 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+//MaxStandardTxWeight weight limit of standard transaction of Bitcoin Core
+const MaxStandardTxWeight int64 = 400000
+
+//estimated sizes of signed inputs, signature push is counted at its largest
+const (
+	txInBaseSize   = 32 + 4 + 4 //outpoint and sequence
+	sigPushSize    = 1 + 72     //DER signature and hash type
+	pubKeyPushSize = 1 + 33
+)
+
+//batch payouts and utxos funding them
+type batchPlan struct {
+	inputs int
+	fee    int64
+	weight int64
+}
+
+//estimateInputWeight weight of input spending prevOut once signed
+func estimateInputWeight(utxo *Utxo, prevOut *wire.TxOut, params *chaincfg.Params) (int64, error) {
+	if utxo.Script != "" {
+		scriptLen := len(utxo.Script) / 2
+		//signature, branch selector and script
+		items := sigPushSize + 2 + wire.VarIntSerializeSize(uint64(scriptLen)) + scriptLen
+
+		switch txscript.GetScriptClass(prevOut.PkScript) {
+		case txscript.WitnessV0ScriptHashTy:
+			return 4*(txInBaseSize+1) + 1 + int64(items), nil
+		case txscript.ScriptHashTy:
+			scriptSig := items + 2
+			return 4*int64(txInBaseSize+wire.VarIntSerializeSize(uint64(scriptSig))+scriptSig) + 1, nil
+		}
+
+		return 0, errors.New("pkscript of script path must be p2sh or p2wsh")
+	}
+
+	witness := int64(1 + sigPushSize + pubKeyPushSize)
+
+	switch txscript.GetScriptClass(prevOut.PkScript) {
+	case txscript.PubKeyHashTy:
+		pubKeySize := pubKeyPushSize
+		if utxo.Private != "" {
+			key, err := parsePrivateKey(utxo.Private, params)
+			if err != nil {
+				return 0, err
+			}
+
+			if _, ok := uncompressedPubKey(prevOut, key.PubKey().SerializeCompressed()); ok {
+				pubKeySize = 1 + 65
+			}
+		}
+
+		return 4*int64(txInBaseSize+1+sigPushSize+pubKeySize) + 1, nil
+	case txscript.WitnessV0PubKeyHashTy:
+		return 4*(txInBaseSize+1) + witness, nil
+	case txscript.ScriptHashTy:
+		//p2sh-p2wpkh, scriptsig pushes the witness program
+		return 4*(txInBaseSize+1+23) + witness, nil
+	}
+
+	return 0, fmt.Errorf("could not estimate size of input %s:%d", utxo.TxID, utxo.OutputIndex)
+}
+
+//estimateTxWeight weight of signed transaction, segwit marker is always counted
+func estimateTxWeight(inputWeights []int64, outputs []*wire.TxOut) int64 {
+	weight := int64(4*(4+4+wire.VarIntSerializeSize(uint64(len(inputWeights)))+
+		wire.VarIntSerializeSize(uint64(len(outputs)))) + 2)
+	for _, w := range inputWeights {
+		weight += w
+	}
+
+	for _, out := range outputs {
+		weight += 4 * int64(out.SerializeSize())
+	}
+
+	return weight
+}
+
+//feeOfWeight fee at feeRate satoshis per virtual byte, virtual size is rounded up
+func feeOfWeight(weight, feeRate int64) int64 {
+	return (weight + 3) / 4 * feeRate
+}
+
+//mergePayouts merge payouts to the same address, keeping the order of first appearance
+func mergePayouts(payouts []BatchTo, params *chaincfg.Params) ([]BatchTo, error) {
+	merged := make([]BatchTo, 0, len(payouts))
+	index := make(map[string]int)
+
+	for _, v := range payouts {
+		if err := checkBTCAddress(v.To, params); err != nil {
+			return nil, err
+		}
+
+		if v.Satoshis <= 0 {
+			return nil, fmt.Errorf("payout to %s should be positive", v.To)
+		}
+
+		addr, err := btcutil.DecodeAddress(v.To, params)
+		if err != nil {
+			return nil, err
+		}
+		key := addr.EncodeAddress()
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, v)
+			continue
+		}
+
+		if merged[i].SubtractFee != v.SubtractFee {
+			return nil, fmt.Errorf("payouts to %s disagree on subtracting fee", v.To)
+		}
+
+		merged[i].Satoshis += v.Satoshis
+	}
+
+	return merged, nil
+}
+
+//planBatch fund payouts by the first utxos of pool, weight includes a change output
+func (input BatchPayoutInput) planBatch(payouts []BatchTo, pool []Utxo, inputWeights []int64,
+	params *chaincfg.Params) (*batchPlan, error) {
+	changeOut, err := getTxOut(input.ChangeAddress, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("change address: %v", err)
+	}
+
+	outputs := []*wire.TxOut{changeOut}
+	amount := int64(0)
+	subtractFee := false
+	for _, v := range payouts {
+		out, err := getTxOut(v.To, v.Satoshis, params)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs = append(outputs, out)
+		amount += v.Satoshis
+		subtractFee = subtractFee || v.SubtractFee
+	}
+
+	//version, locktime, output count and segwit marker
+	weight := int64(4*(4+4+wire.VarIntSerializeSize(uint64(len(outputs)))) + 2)
+	for _, out := range outputs {
+		weight += 4 * int64(out.SerializeSize())
+	}
+
+	funds := int64(0)
+	for n := 1; n <= len(pool); n++ {
+		funds += pool[n-1].Satoshis
+		weight += inputWeights[n-1]
+
+		txWeight := weight + 4*int64(wire.VarIntSerializeSize(uint64(n)))
+		fee := feeOfWeight(txWeight, input.FeeRate)
+
+		need := amount
+		if !subtractFee {
+			need += fee
+		}
+
+		if funds >= need {
+			return &batchPlan{inputs: n, fee: fee, weight: txWeight}, nil
+		}
+	}
+
+	return nil, errors.New("insufficient funds for payouts")
+}
+
+//subtractFee take fee from SubtractFee payouts in equal shares, the first one pays the remainder
+func subtractFee(payouts []BatchTo, fee int64) ([]WlTo, error) {
+	count := int64(0)
+	for _, v := range payouts {
+		if v.SubtractFee {
+			count++
+		}
+	}
+
+	to := make([]WlTo, 0, len(payouts))
+	first := true
+	for _, v := range payouts {
+		amount := v.Satoshis
+		if v.SubtractFee {
+			amount -= fee / count
+			if first {
+				amount -= fee % count
+				first = false
+			}
+
+			if amount <= 0 {
+				return nil, fmt.Errorf("payout to %s can not pay its share of fee %d", v.To, fee)
+			}
+		}
+
+		to = append(to, WlTo{To: v.To, Satoshis: amount})
+	}
+
+	return to, nil
+}
+
+//batchPayout split merged payouts into transactions under weight limit, spending utxos in order
+func batchPayout(input BatchPayoutInput, signer hdwallet.Signer) (*BatchPayout, error) {
+	params, err := hdwallet.GetNetParams(input.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.FeeRate <= 0 {
+		return nil, errors.New("fee rate should be positive")
+	}
+
+	if input.MaxWeight <= 0 {
+		input.MaxWeight = MaxStandardTxWeight
+	}
+
+	if err := checkBTCAddress(input.ChangeAddress, params); err != nil {
+		return nil, fmt.Errorf("change address: %v", err)
+	}
+
+	payouts, err := mergePayouts(input.To, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payouts) == 0 {
+		return nil, errors.New("no payouts")
+	}
+
+	weights, err := inputWeights(input.Utxos, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchPayout{}
+	pool, poolWeights := input.Utxos, weights
+	for start := 0; start < len(payouts); {
+		plan, err := input.planBatch(payouts[start:start+1], pool, poolWeights, params)
+		if err != nil {
+			return nil, fmt.Errorf("payout to %s: %v", payouts[start].To, err)
+		}
+
+		if plan.weight > input.MaxWeight {
+			return nil, fmt.Errorf("payout to %s needs weight %d over limit %d", payouts[start].To, plan.weight, input.MaxWeight)
+		}
+
+		//add payouts while the transaction stays under weight limit
+		end := start + 1
+		for ; end < len(payouts); end++ {
+			next, err := input.planBatch(payouts[start:end+1], pool, poolWeights, params)
+			if err != nil || next.weight > input.MaxWeight {
+				break
+			}
+			plan = next
+		}
+
+		to, err := subtractFee(payouts[start:end], plan.fee)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, droppedChange, err := buildBTCTx(BTCTxInput{
+			CoinType:      input.CoinType,
+			Network:       input.Network,
+			Utxos:         pool[:plan.inputs],
+			To:            to,
+			ChangeAddress: input.ChangeAddress,
+			Fee:           plan.fee,
+			LockTime:      input.LockTime,
+			DustRelayFee:  input.DustRelayFee,
+		}, signer)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Transactions = append(result.Transactions, BatchTransaction{
+			TransactionBTC: TransactionBTC{
+				TxID:          tx.TxHash().String(),
+				HexTx:         txToHex(tx),
+				DroppedChange: droppedChange,
+			},
+			Fee: plan.fee + droppedChange,
+			To:  to,
+		})
+		result.Fee += plan.fee + droppedChange
+
+		pool, poolWeights = pool[plan.inputs:], poolWeights[plan.inputs:]
+		start = end
+	}
+
+	return result, nil
+}
+
+//BatchPayoutBTC make one or more transactions paying many recipients, json of BatchPayoutInput in and BatchPayout out
+func BatchPayoutBTC(batch string) (string, error) {
+	return BatchPayoutBTCWithSigner(batch, nil)
+}
+
+//BatchPayoutBTCWithSigner like BatchPayoutBTC, utxos without private key are signed by signer with Utxo.KeyID
+func BatchPayoutBTCWithSigner(batch string, signer hdwallet.Signer) (string, error) {
+	var input BatchPayoutInput
+	if err := json.Unmarshal([]byte(batch), &input); err != nil {
+		return "", err
+	}
+
+	result, err := batchPayout(input, signer)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -1,35 +1,117 @@
 package blockchain
 
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+//InputError error of signing or validating a transaction input
+type InputError struct {
+	Index       int
+	TxID        string
+	OutputIndex uint32
+	Err         error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("input %d (%s:%d): %v", e.Index, e.TxID, e.OutputIndex, e.Err)
+}
+
+func newInputError(tx *wire.MsgTx, i int, err error) error {
+	outPoint := tx.TxIn[i].PreviousOutPoint
+	return &InputError{
+		Index:       i,
+		TxID:        outPoint.Hash.String(),
+		OutputIndex: outPoint.Index,
+		Err:         err,
+	}
+}
+
 //TransactionBTC btc transaction object
 type TransactionBTC struct {
-	TxID  string
-	HexTx string
+	TxID          string
+	HexTx         string
+	DroppedChange int64 //change left to miner for being below dust threshold
 }
 
 //BTCTxInput input of building BTC
 type BTCTxInput struct {
-	CoinType       string `json:"cointype"`
-	Utxos          []Utxo `json:"utxos"`
-	To             []WlTo `json:"to"`
-	ChangeAddress  string `json:"changeaddress"`
-	Fee            int64  `json:"fee"`
-	BlockHash      string `json:"blockhash"`
-	Dust           int64  `json:"dust"`
-	OmniAddress    string `json:"omniAddress"`
-	OmniCurrencyID int64  `json:"omniCurrencyID"`
-	OmniAmount     int64  `json:"omniAmount"`
-	NeedOmniOut    int    `json:"needOmniOut"`
+	CoinType       string       `json:"cointype"`
+	Network        string       `json:"network"` //mainnet(default), testnet, signet or regtest
+	Utxos          []Utxo       `json:"utxos"`
+	To             []WlTo       `json:"to"`
+	ChangeAddress  string       `json:"changeaddress"`
+	Fee            int64        `json:"fee"`
+	BlockHash      string       `json:"blockhash"`
+	Dust           int64        `json:"dust"`
+	OmniAddress    string       `json:"omniAddress"` //reference recipient, replaces To of omni transaction
+	Omni           *OmniPayload `json:"omni"`        //any omni payload, OmniCurrencyID and OmniAmount make a simple send
+	OmniCurrencyID int64        `json:"omniCurrencyID"`
+	OmniAmount     int64        `json:"omniAmount"`
+	NeedOmniOut    int          `json:"needOmniOut"`
+	LockTime       uint32       `json:"locktime"`
+	Data           *DataOutput  `json:"data"`         //OP_RETURN output after recipients, not with omni
+	DustRelayFee   int64        `json:"dustrelayfee"` //satoshis/kvB of dust threshold, DefaultDustRelayFee by default
+	//privacy options
+	OutputOrder      string   `json:"outputorder"`      //change first by default or last with SIGHASH_SINGLE inputs, bip69 or random
+	AntiFeeSniping   bool     `json:"antifeesniping"`   //set LockTime to BlockHeight when LockTime is 0
+	BlockHeight      uint32   `json:"blockheight"`      //current block height
+	ChangeCandidates []string `json:"changecandidates"` //change addresses of different types, the one matching recipients is used
+}
+
+//DataOutput OP_RETURN output for memo or notarisation, either Hex or UTF-8 Text up to 80 bytes
+type DataOutput struct {
+	Hex  string `json:"hex"`
+	Text string `json:"text"`
+}
+
+//OmniSendInput input of TransferOmni, simple send of tokens to a single reference recipient
+type OmniSendInput struct {
+	CoinType      string `json:"cointype"`
+	Network       string `json:"network"` //mainnet(default), testnet, signet or regtest
+	Utxos         []Utxo `json:"utxos"`   //all utxos belong to the token sender
+	To            string `json:"to"`      //reference recipient of tokens
+	ChangeAddress string `json:"changeaddress"`
+	Fee           int64  `json:"fee"`
+	Dust          int64  `json:"dust"` //satoshis of reference output, dust threshold of To by default
+	PropertyID    uint32 `json:"propertyid"`
+	Amount        string `json:"amount"` //token amount like "1.5", see OmniAmount
+	Divisible     bool   `json:"divisible"`
+	LockTime      uint32 `json:"locktime"`
+	DustRelayFee  int64  `json:"dustrelayfee"` //satoshis/kvB of dust threshold, DefaultDustRelayFee by default
 }
 
 //Utxo btc input
 type Utxo struct {
-	Address     string `json:"address"`
-	TxID        string `json:"txid"`
-	OutputIndex int    `json:"outputindex"`
-	PkScript    string `json:"pkscript"` //last publickeyscript of utxo.vout
-	Satoshis    int64  `json:"satoshis"`
-	Public      string `json:"public"`
-	Private     string `json:"private"`
+	Address     string  `json:"address"`
+	TxID        string  `json:"txid"`
+	OutputIndex int     `json:"outputindex"`
+	PkScript    string  `json:"pkscript"` //last publickeyscript of utxo.vout
+	Satoshis    int64   `json:"satoshis"`
+	Public      string  `json:"public"`
+	Private     string  `json:"private"`            //hex or WIF
+	KeyID       string  `json:"keyid"`              //key of external signer when Private is empty, see TransferBTCWithSigner
+	Sequence    *uint32 `json:"sequence,omitempty"` //nil means CurrentTxInSequenceNum, any set sequence makes tx version 2
+	Script      string  `json:"script"`             //redeem or witness script of script path, see CreateTimelockAddress
+	SigHash     string  `json:"sighash"`            //ALL(default), NONE or SINGLE, with optional |ANYONECANPAY
+}
+
+//TimelockInfo vault which PubKey can only spend after LockValue, OwnerPubKey(optional) can spend at any time
+type TimelockInfo struct {
+	PubKey      string `json:"pubkey"`
+	OwnerPubKey string `json:"ownerpubkey"`
+	LockType    string `json:"locktype"` //cltv: LockValue is block height or unix time; csv: LockValue is BIP-68 relative sequence
+	LockValue   int64  `json:"lockvalue"`
+	ScriptType  string `json:"scripttype"` //p2sh or p2wsh
+	Network     string `json:"network"`
+}
+
+//TimelockAddress address of timelocked vault
+type TimelockAddress struct {
+	Address    string `json:"address"`
+	Script     string `json:"script"`
+	ScriptType string `json:"scripttype"`
 }
 
 //WlTo btc output
@@ -38,6 +120,125 @@ type WlTo struct {
 	Satoshis int64  `json:"satoshis"`
 }
 
+//BatchTo payout of BatchPayoutBTC, SubtractFee outputs pay the fee of their transaction in equal shares
+type BatchTo struct {
+	To          string `json:"to"`
+	Satoshis    int64  `json:"satoshis"`
+	SubtractFee bool   `json:"subtractfee"`
+}
+
+//BatchPayoutInput input of BatchPayoutBTC, payouts to the same address are merged
+type BatchPayoutInput struct {
+	CoinType      string    `json:"cointype"`
+	Network       string    `json:"network"` //mainnet(default), testnet, signet or regtest
+	Utxos         []Utxo    `json:"utxos"`   //spent in the given order
+	To            []BatchTo `json:"to"`
+	ChangeAddress string    `json:"changeaddress"`
+	FeeRate       int64     `json:"feerate"`   //satoshis per virtual byte
+	MaxWeight     int64     `json:"maxweight"` //weight limit of every transaction, MaxStandardTxWeight by default
+	LockTime      uint32    `json:"locktime"`
+	DustRelayFee  int64     `json:"dustrelayfee"` //satoshis/kvB of dust threshold, DefaultDustRelayFee by default
+}
+
+//BatchTransaction transaction of batch payout
+type BatchTransaction struct {
+	TransactionBTC
+	Fee int64  `json:"fee"`
+	To  []WlTo `json:"to"` //payouts after fee subtraction
+}
+
+//BatchPayout result of BatchPayoutBTC
+type BatchPayout struct {
+	Transactions []BatchTransaction `json:"transactions"`
+	Fee          int64              `json:"fee"`
+}
+
+//SweepInput input of SweepBTC, spends utxos to To with fee taken from the total
+type SweepInput struct {
+	CoinType     string   `json:"cointype"`
+	Network      string   `json:"network"` //mainnet(default), testnet, signet or regtest
+	Utxos        []Utxo   `json:"utxos"`
+	Selected     []string `json:"selected"` //outpoints "txid:index" of utxos to spend, all utxos by default
+	To           string   `json:"to"`
+	FeeRate      int64    `json:"feerate"` //satoshis per virtual byte
+	LockTime     uint32   `json:"locktime"`
+	DustRelayFee int64    `json:"dustrelayfee"` //satoshis/kvB of dust threshold, DefaultDustRelayFee by default
+}
+
+//SweepTransaction result of SweepBTC
+type SweepTransaction struct {
+	TransactionBTC
+	Fee      int64 `json:"fee"`
+	Satoshis int64 `json:"satoshis"` //amount received by To
+}
+
+//ConsolidationInput input of PlanConsolidation
+type ConsolidationInput struct {
+	Network         string `json:"network"` //mainnet(default), testnet, signet or regtest
+	Utxos           []Utxo `json:"utxos"`
+	To              string `json:"to"`              //address receiving the merged output
+	FeeRate         int64  `json:"feerate"`         //current satoshis per virtual byte
+	LongTermFeeRate int64  `json:"longtermfeerate"` //expected satoshis per virtual byte when the utxos are spent later
+	MaxWeight       int64  `json:"maxweight"`       //weight limit of consolidation, MaxStandardTxWeight by default
+}
+
+//ConsolidationPlan result of PlanConsolidation, Selected can be passed to SweepInput
+type ConsolidationPlan struct {
+	Worthwhile   bool     `json:"worthwhile"`   //merging now costs less than spending the utxos one by one later
+	Selected     []string `json:"selected"`     //outpoints "txid:index" to merge, smallest first
+	Satoshis     int64    `json:"satoshis"`     //total of selected utxos
+	Fee          int64    `json:"fee"`          //fee of consolidation at FeeRate
+	Savings      int64    `json:"savings"`      //later fees saved minus fee paid now, negative if not worthwhile
+	Uneconomical []string `json:"uneconomical"` //outpoints worth less than the fee of spending them at FeeRate
+}
+
+//PaperWalletAddress candidate address of an imported private key
+type PaperWalletAddress struct {
+	Address    string `json:"address"`
+	Type       string `json:"type"` //p2pkh, p2sh-p2wpkh or p2wpkh
+	Compressed bool   `json:"compressed"`
+	PkScript   string `json:"pkscript"`
+}
+
+//PaperWalletSweepInput input of SweepPaperWallet, Utxos are of addresses from PaperWalletAddresses
+type PaperWalletSweepInput struct {
+	CoinType     string `json:"cointype"`
+	Network      string `json:"network"`    //mainnet(default), testnet, signet or regtest
+	PrivateKey   string `json:"privatekey"` //WIF or hex
+	Utxos        []Utxo `json:"utxos"`      //private key and pkscript are filled from PrivateKey
+	To           string `json:"to"`         //fresh address of HD wallet
+	FeeRate      int64  `json:"feerate"`    //satoshis per virtual byte
+	LockTime     uint32 `json:"locktime"`
+	DustRelayFee int64  `json:"dustrelayfee"` //satoshis/kvB of dust threshold, DefaultDustRelayFee by default
+}
+
+func (input BTCTxInput) isOmni() bool {
+	return input.Omni != nil || input.OmniCurrencyID != 0
+}
+
+//getOmniPayload payload of omni transaction, legacy fields make a simple send
+func (input BTCTxInput) getOmniPayload() *OmniPayload {
+	if input.Omni != nil {
+		return input.Omni
+	}
+
+	return &OmniPayload{
+		Type:       OmniTypeSimpleSend,
+		PropertyID: uint32(input.OmniCurrencyID),
+		Amount:     input.OmniAmount,
+	}
+}
+
+func hasSequence(utxos []Utxo) bool {
+	for _, txin := range utxos {
+		if txin.Sequence != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (input BTCTxInput) getChangeAmount() int64 {
 	toAmount := int64(0)
 	for _, txout := range input.To {
@@ -52,7 +253,7 @@ func (input BTCTxInput) getChangeAmount() int64 {
 
 	valueNeed := toAmount + input.Fee
 
-	if input.OmniCurrencyID != 0 {
+	if input.isOmni() {
 		valueNeed -= input.Dust
 	}
 
@@ -60,3 +261,114 @@ func (input BTCTxInput) getChangeAmount() int64 {
 
 	return changeAmount
 }
+
+//MultisigInfo m-of-n sorted multisig wallet(BIP-67) built from account xpubs
+type MultisigInfo struct {
+	XPubs      []string `json:"xpubs"`
+	Required   int      `json:"required"`
+	ScriptType string   `json:"scripttype"` //p2sh, p2wsh or p2sh-p2wsh
+	Network    string   `json:"network"`
+}
+
+//MultisigAddress address of multisig wallet at change/index
+type MultisigAddress struct {
+	Address       string   `json:"address"`
+	ScriptType    string   `json:"scripttype"`
+	RedeemScript  string   `json:"redeemscript"`
+	WitnessScript string   `json:"witnessscript"`
+	PubKeys       []string `json:"pubkeys"`
+	Change        int      `json:"change"`
+	Index         int      `json:"index"`
+}
+
+//MultisigUtxo multisig input
+type MultisigUtxo struct {
+	TxID        string `json:"txid"`
+	OutputIndex int    `json:"outputindex"`
+	Satoshis    int64  `json:"satoshis"`
+	Change      int    `json:"change"`
+	Index       int    `json:"index"`
+	PrevTx      string `json:"prevtx"` //raw previous transaction in hex, required by p2sh inputs
+}
+
+//MultisigTxInput input of building multisig psbt
+type MultisigTxInput struct {
+	Wallet        MultisigInfo   `json:"wallet"`
+	Utxos         []MultisigUtxo `json:"utxos"`
+	To            []WlTo         `json:"to"`
+	ChangeAddress string         `json:"changeaddress"`
+	Fee           int64          `json:"fee"`
+	DustRelayFee  int64          `json:"dustrelayfee"` //satoshis/kvB of dust threshold, DefaultDustRelayFee by default
+}
+
+//PSBTSignInput input of signing psbt
+type PSBTSignInput struct {
+	PSBT     string   `json:"psbt"`
+	Privates []string `json:"privates"`
+	KeyIDs   []string `json:"keyids"` //keys of external signer, see SignMultisigPSBTWithSigner
+}
+
+func (input MultisigTxInput) getChangeAmount() int64 {
+	toAmount := int64(0)
+	for _, txout := range input.To {
+		toAmount += txout.Satoshis
+	}
+
+	fromAmount := int64(0)
+	for _, txin := range input.Utxos {
+		fromAmount += txin.Satoshis
+	}
+
+	return fromAmount - toAmount - input.Fee
+}
+
+//DecodedBTCTx readable btc transaction of DecodeBTCTransaction
+type DecodedBTCTx struct {
+	TxID     string         `json:"txid"`
+	Version  int32          `json:"version"`
+	LockTime uint32         `json:"locktime"`
+	Size     int            `json:"size"`
+	VSize    int            `json:"vsize"`
+	Weight   int            `json:"weight"`
+	RBF      bool           `json:"rbf"`
+	IsPSBT   bool           `json:"ispsbt"`
+	HasFee   bool           `json:"hasfee"` //fee is known only when amounts of all inputs are given
+	Fee      int64          `json:"fee"`
+	Inputs   []DecodedTxIn  `json:"inputs"`
+	Outputs  []DecodedTxOut `json:"outputs"`
+}
+
+//DecodedTxIn input of DecodedBTCTx
+type DecodedTxIn struct {
+	TxID        string   `json:"txid"`
+	OutputIndex uint32   `json:"outputindex"`
+	Sequence    uint32   `json:"sequence"`
+	ScriptSig   string   `json:"scriptsig"`
+	Witness     []string `json:"witness"`
+	Satoshis    int64    `json:"satoshis"` //0 if unknown
+}
+
+//DecodedTxOut output of DecodedBTCTx
+type DecodedTxOut struct {
+	Index    int          `json:"index"`
+	Satoshis int64        `json:"satoshis"`
+	PkScript string       `json:"pkscript"`
+	Type     string       `json:"type"`
+	Address  string       `json:"address"`
+	Data     string       `json:"data,omitempty"` //hex data of OP_RETURN output
+	Omni     *OmniPayload `json:"omni,omitempty"`
+}
+
+//OmniPayload omni layer payload of OP_RETURN output, amounts are in willets, see OmniAmount
+type OmniPayload struct {
+	Version           uint16 `json:"version"`
+	Type              uint16 `json:"type"`
+	PropertyID        uint32 `json:"propertyid,omitempty"`
+	Amount            int64  `json:"amount,omitempty"`
+	Ecosystem         uint8  `json:"ecosystem,omitempty"` //1 main, 2 test
+	DesiredPropertyID uint32 `json:"desiredpropertyid,omitempty"`
+	DesiredAmount     int64  `json:"desiredamount,omitempty"` //satoshis of DEx sell offer
+	PaymentWindow     uint8  `json:"paymentwindow,omitempty"` //blocks of DEx sell offer
+	MinFee            int64  `json:"minfee,omitempty"`        //satoshis of DEx sell offer
+	Action            uint8  `json:"action,omitempty"`        //DEx sell offer: 1 new, 2 update, 3 cancel
+}
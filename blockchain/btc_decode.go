@@ -0,0 +1,167 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/psbt"
+)
+
+//psbtMagic magic bytes of psbt: "psbt" 0xff
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+//parseTxOrPSBT parse raw transaction hex, psbt hex or psbt base64
+func parseTxOrPSBT(rawTx string) (*wire.MsgTx, *psbt.Packet, error) {
+	rawTx = strings.TrimSpace(rawTx)
+
+	raw, err := hex.DecodeString(rawTx)
+	if err != nil {
+		//psbt in base64
+		raw, err = base64.StdEncoding.DecodeString(rawTx)
+		if err != nil || !bytes.HasPrefix(raw, psbtMagic) {
+			return nil, nil, fmt.Errorf("transaction should be hex or base64 psbt")
+		}
+	}
+
+	if bytes.HasPrefix(raw, psbtMagic) {
+		packet, err := psbt.NewFromRawBytes(bytes.NewReader(raw), false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decode psbt: %v", err)
+		}
+
+		return packet.UnsignedTx, packet, nil
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, nil, fmt.Errorf("deserialize transaction: %v", err)
+	}
+
+	return tx, nil, nil
+}
+
+//DecodeBTCTransaction decode raw transaction or psbt into json of DecodedBTCTx.
+//prevAmounts is optional json array of input amounts in satoshis, psbt inputs carry their own.
+func DecodeBTCTransaction(rawTx, prevAmounts, network string) (string, error) {
+	params, err := hdwallet.GetNetParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	tx, packet, err := parseTxOrPSBT(rawTx)
+	if err != nil {
+		return "", err
+	}
+
+	amounts := make([]int64, 0)
+	if prevAmounts != "" {
+		if err := json.Unmarshal([]byte(prevAmounts), &amounts); err != nil {
+			return "", fmt.Errorf("unmarshal prevAmounts: %v", err)
+		}
+
+		if len(amounts) != len(tx.TxIn) {
+			return "", fmt.Errorf("transaction has %d inputs but %d amounts", len(tx.TxIn), len(amounts))
+		}
+	}
+
+	//a psbt tx is unsigned, size is only an estimation without signatures
+	stripped := tx.SerializeSizeStripped()
+	size := tx.SerializeSize()
+	weight := stripped*3 + size
+
+	result := &DecodedBTCTx{
+		TxID:     tx.TxHash().String(),
+		Version:  tx.Version,
+		LockTime: tx.LockTime,
+		Size:     size,
+		VSize:    (weight + 3) / 4,
+		Weight:   weight,
+		IsPSBT:   packet != nil,
+		Inputs:   make([]DecodedTxIn, 0, len(tx.TxIn)),
+		Outputs:  make([]DecodedTxOut, 0, len(tx.TxOut)),
+	}
+
+	inAmount := int64(0)
+	knownAmounts := 0
+	for i, txIn := range tx.TxIn {
+		in := DecodedTxIn{
+			TxID:        txIn.PreviousOutPoint.Hash.String(),
+			OutputIndex: txIn.PreviousOutPoint.Index,
+			Sequence:    txIn.Sequence,
+			ScriptSig:   hex.EncodeToString(txIn.SignatureScript),
+			Witness:     make([]string, 0, len(txIn.Witness)),
+		}
+
+		for _, wit := range txIn.Witness {
+			in.Witness = append(in.Witness, hex.EncodeToString(wit))
+		}
+
+		//BIP-125: any input with sequence below 0xfffffffe signals replaceability
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			result.RBF = true
+		}
+
+		if len(amounts) > 0 {
+			in.Satoshis = amounts[i]
+			knownAmounts++
+		} else if packet != nil {
+			if prevOut, err := psbtPrevOut(packet, i); err == nil {
+				in.Satoshis = prevOut.Value
+				knownAmounts++
+			}
+		}
+		inAmount += in.Satoshis
+
+		result.Inputs = append(result.Inputs, in)
+	}
+
+	outAmount := int64(0)
+	for i, txOut := range tx.TxOut {
+		out := DecodedTxOut{
+			Index:    i,
+			Satoshis: txOut.Value,
+			PkScript: hex.EncodeToString(txOut.PkScript),
+		}
+
+		class, addrs, _, err := txscript.ExtractPkScriptAddrs(txOut.PkScript, params)
+		if err == nil {
+			out.Type = class.String()
+			if len(addrs) == 1 {
+				out.Address = addrs[0].EncodeAddress()
+			}
+		}
+
+		if class == txscript.NullDataTy {
+			pushes, err := txscript.PushedData(txOut.PkScript)
+			if err == nil && len(pushes) > 0 {
+				out.Data = hex.EncodeToString(pushes[0])
+				if omni, err := decodeOmniData(pushes[0]); err == nil {
+					out.Omni = omni
+				}
+			}
+		}
+		outAmount += txOut.Value
+
+		result.Outputs = append(result.Outputs, out)
+	}
+
+	if len(tx.TxIn) > 0 && knownAmounts == len(tx.TxIn) {
+		result.HasFee = true
+		result.Fee = inAmount - outAmount
+	}
+
+	strResult, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	return string(strResult), nil
+}
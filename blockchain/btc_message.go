@@ -0,0 +1,424 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+//messageMagic prefix of legacy signed message
+const messageMagic = "Bitcoin Signed Message:\n"
+
+//compact signature headers of BIP-137, plus recovery id 0-3
+const (
+	headerP2PKHUncompressed byte = 27
+	headerP2PKHCompressed   byte = 31
+	headerP2SHP2WPKH        byte = 35
+	headerP2WPKH            byte = 39
+)
+
+//legacyMessageHash double sha256 of magic and message with var length prefixes
+func legacyMessageHash(message string) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, messageMagic)
+	wire.WriteVarString(&buf, 0, message)
+
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+//bip322MessageHash tagged hash of message
+func bip322MessageHash(message string) []byte {
+	return hdwallet.TaggedHash("BIP0322-signed-message", []byte(message))
+}
+
+//bip322ToSpend virtual transaction whose output is spent by the signature
+func bip322ToSpend(pkScript []byte, message string) (*wire.MsgTx, error) {
+	scriptSig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(bip322MessageHash(message)).Script()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(0)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), scriptSig, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	return tx, nil
+}
+
+//bip322ToSign virtual transaction carrying the signature in witness
+func bip322ToSign(toSpend *wire.MsgTx) *wire.MsgTx {
+	hash := toSpend.TxHash()
+
+	tx := wire.NewMsgTx(0)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&hash, 0), nil, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+
+	return tx
+}
+
+//decodeMessageAddress address of message signature, bech32m taproot address is decoded here
+//since btcutil does not know it
+func decodeMessageAddress(address string, params *chaincfg.Params) (btcutil.Address, error) {
+	if program, err := decodeTaprootAddress(address, params); err == nil {
+		return &taprootAddress{program: program, params: params}, nil
+	}
+
+	if err := checkBTCAddress(address, params); err != nil {
+		return nil, err
+	}
+
+	return btcutil.DecodeAddress(address, params)
+}
+
+//messagePkScript output script of address
+func messagePkScript(addr btcutil.Address) ([]byte, error) {
+	if taproot, ok := addr.(*taprootAddress); ok {
+		return taproot.pkScript()
+	}
+
+	return txscript.PayToAddrScript(addr)
+}
+
+//SignBTCMessage sign message with hex private key of address: legacy signature for P2PKH and
+//P2SH-P2WPKH, BIP-322 simple signature for P2WPKH and P2TR. Result is base64.
+func SignBTCMessage(privateKey, address, message, network string) (string, error) {
+	signer, err := hdwallet.NewHexKeySigner(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return SignBTCMessageWithSigner("", address, message, network, signer)
+}
+
+//SignBTCMessageWithSigner like SignBTCMessage, message is signed by signer with keyID,
+//P2TR needs signer to be hdwallet.SchnorrSigner
+func SignBTCMessageWithSigner(keyID, address, message, network string, signer hdwallet.Signer) (string, error) {
+	params, err := hdwallet.GetNetParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	addr, err := decodeMessageAddress(address, params)
+	if err != nil {
+		return "", err
+	}
+
+	pkData, err := signer.PublicKey(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	if !messageKeyMatch(addr, pkData, params) {
+		return "", fmt.Errorf("key does not belong to address %s", address)
+	}
+
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return signLegacyMessage(message, headerP2PKHCompressed, pkData, signer, keyID)
+	case *btcutil.AddressScriptHash:
+		return signLegacyMessage(message, headerP2SHP2WPKH, pkData, signer, keyID)
+	case *btcutil.AddressWitnessPubKeyHash:
+		return signBIP322Message(addr, message, pkData, signer, keyID)
+	case *taprootAddress:
+		return signBIP322TaprootMessage(addr, message, pkData, signer, keyID)
+	default:
+		return "", fmt.Errorf("address %s is not support", address)
+	}
+}
+
+//messageKeyMatch compressed public key is of P2PKH, P2SH-P2WPKH, P2WPKH or key path P2TR address
+func messageKeyMatch(addr btcutil.Address, pkData []byte, params *chaincfg.Params) bool {
+	keyHash := btcutil.Hash160(pkData)
+
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressWitnessPubKeyHash:
+		return bytes.Equal(addr.ScriptAddress(), keyHash)
+	case *btcutil.AddressScriptHash:
+		if len(pkData) != btcec.PubKeyBytesLenCompressed {
+			return false
+		}
+
+		witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(keyHash, params)
+		if err != nil {
+			return false
+		}
+
+		program, err := txscript.PayToAddrScript(witnessAddr)
+		if err != nil {
+			return false
+		}
+
+		return bytes.Equal(addr.ScriptAddress(), btcutil.Hash160(program))
+	case *taprootAddress:
+		outputKey, err := hdwallet.TaprootOutputKey(pkData)
+		if err != nil {
+			return false
+		}
+
+		return bytes.Equal(addr.ScriptAddress(), outputKey)
+	}
+
+	return false
+}
+
+func signLegacyMessage(message string, header byte, pkData []byte, signer hdwallet.Signer, keyID string) (string, error) {
+	hash := legacyMessageHash(message)
+
+	sig, err := signer.SignDigest(keyID, hash)
+	if err != nil {
+		return "", fmt.Errorf("could not generate signature: %v", err)
+	}
+
+	compact, err := hdwallet.CompactSignature(pkData, hash, sig)
+	if err != nil {
+		return "", err
+	}
+
+	//compact header is of compressed P2PKH
+	compact[0] += header - headerP2PKHCompressed
+
+	return base64.StdEncoding.EncodeToString(compact), nil
+}
+
+func signBIP322Message(addr btcutil.Address, message string, pkData []byte, signer hdwallet.Signer, keyID string) (string, error) {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return "", err
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return "", err
+	}
+
+	toSign := bip322ToSign(toSpend)
+	sig, err := rawTxInWitnessSignature(toSign, txscript.NewTxSigHashes(toSign), 0, 0,
+		pkScript, txscript.SigHashAll, signer, keyID)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeBIP322Witness(wire.TxWitness{sig, pkData})
+}
+
+//signBIP322TaprootMessage BIP-340 signature of key path with SIGHASH_DEFAULT, by key tweaked without script tree
+func signBIP322TaprootMessage(addr btcutil.Address, message string, pkData []byte, signer hdwallet.Signer, keyID string) (string, error) {
+	schnorrSigner, ok := signer.(hdwallet.SchnorrSigner)
+	if !ok {
+		return "", errors.New("signer does not support schnorr signatures")
+	}
+
+	pkScript, err := messagePkScript(addr)
+	if err != nil {
+		return "", err
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return "", err
+	}
+
+	toSign := bip322ToSign(toSpend)
+	digest, err := taprootSigHash(toSign, []*wire.TxOut{toSpend.TxOut[0]}, 0, 0)
+	if err != nil {
+		return "", err
+	}
+
+	tweak, err := hdwallet.TaprootTweak(pkData)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := schnorrSigner.SignSchnorr(keyID, digest, tweak)
+	if err != nil {
+		return "", fmt.Errorf("could not generate signature: %v", err)
+	}
+
+	return encodeBIP322Witness(wire.TxWitness{sig})
+}
+
+//encodeBIP322Witness base64 of witness stack serialized as in transaction
+func encodeBIP322Witness(witness wire.TxWitness) (string, error) {
+	var buf bytes.Buffer
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return "", err
+	}
+
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return "", err
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+//decodeBIP322Witness witness stack of signature, every item takes at least one byte
+func decodeBIP322Witness(sig []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(sig)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil || count > uint64(reader.Len()) {
+		return nil, errors.New("invalid BIP-322 witness")
+	}
+
+	var witness wire.TxWitness
+	for i := uint64(0); i < count; i++ {
+		item, err := wire.ReadVarBytes(reader, 0, txscript.MaxScriptSize, "witness item")
+		if err != nil {
+			return nil, errors.New("invalid BIP-322 witness")
+		}
+		witness = append(witness, item)
+	}
+
+	if reader.Len() != 0 {
+		return nil, errors.New("invalid BIP-322 witness")
+	}
+
+	return witness, nil
+}
+
+//VerifyBTCMessage verify base64 signature of message by address, legacy signature of
+//P2PKH, P2SH-P2WPKH and P2WPKH, or BIP-322 simple signature of P2WPKH and P2TR
+func VerifyBTCMessage(address, message, signature, network string) (bool, error) {
+	params, err := hdwallet.GetNetParams(network)
+	if err != nil {
+		return false, err
+	}
+
+	addr, err := decodeMessageAddress(address, params)
+	if err != nil {
+		return false, err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return false, fmt.Errorf("signature should be base64: %v", err)
+	}
+
+	if len(sig) == 65 {
+		return verifyLegacyMessage(addr, message, sig, params)
+	}
+
+	switch addr := addr.(type) {
+	case *btcutil.AddressWitnessPubKeyHash:
+		return verifyBIP322Message(addr, message, sig)
+	case *taprootAddress:
+		return verifyBIP322TaprootMessage(addr, message, sig)
+	}
+
+	return false, fmt.Errorf("signature of %d bytes is not a legacy signature", len(sig))
+}
+
+func verifyLegacyMessage(addr btcutil.Address, message string, sig []byte, params *chaincfg.Params) (bool, error) {
+	header := sig[0]
+	if header < headerP2PKHUncompressed || header >= headerP2WPKH+4 {
+		return false, fmt.Errorf("invalid signature header %d", header)
+	}
+
+	compressed := header >= headerP2PKHCompressed
+	recoverSig := append([]byte{}, sig...)
+	recoverSig[0] = headerP2PKHUncompressed + (header-headerP2PKHUncompressed)%4
+	if compressed {
+		recoverSig[0] += 4
+	}
+
+	pubKey, _, err := btcec.RecoverCompact(btcec.S256(), recoverSig, legacyMessageHash(message))
+	if err != nil {
+		return false, nil
+	}
+
+	pkData := pubKey.SerializeUncompressed()
+	if compressed {
+		pkData = pubKey.SerializeCompressed()
+	}
+
+	return messageKeyMatch(addr, pkData, params), nil
+}
+
+func verifyBIP322Message(addr btcutil.Address, message string, sig []byte) (bool, error) {
+	witness, err := decodeBIP322Witness(sig)
+	if err != nil {
+		return false, err
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return false, err
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return false, err
+	}
+
+	toSign := bip322ToSign(toSpend)
+	toSign.TxIn[0].Witness = witness
+
+	vm, err := txscript.NewEngine(pkScript, toSign, 0, txscript.StandardVerifyFlags,
+		nil, txscript.NewTxSigHashes(toSign), 0)
+	if err != nil {
+		return false, nil
+	}
+
+	return vm.Execute() == nil, nil
+}
+
+//verifyBIP322TaprootMessage key path witness of one BIP-340 signature, 64 bytes of SIGHASH_DEFAULT
+//or 65 bytes ending with SIGHASH_ALL
+func verifyBIP322TaprootMessage(addr *taprootAddress, message string, sig []byte) (bool, error) {
+	witness, err := decodeBIP322Witness(sig)
+	if err != nil {
+		return false, err
+	}
+
+	if len(witness) != 1 {
+		return false, nil
+	}
+
+	schnorrSig := witness[0]
+	hashType := txscript.SigHashType(0)
+	switch len(schnorrSig) {
+	case 64:
+	case 65:
+		hashType = txscript.SigHashType(schnorrSig[64])
+		if hashType != txscript.SigHashAll {
+			return false, nil
+		}
+		schnorrSig = schnorrSig[:64]
+	default:
+		return false, nil
+	}
+
+	pkScript, err := addr.pkScript()
+	if err != nil {
+		return false, err
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return false, err
+	}
+
+	toSign := bip322ToSign(toSpend)
+	digest, err := taprootSigHash(toSign, []*wire.TxOut{toSpend.TxOut[0]}, 0, hashType)
+	if err != nil {
+		return false, err
+	}
+
+	return hdwallet.VerifySchnorr(addr.ScriptAddress(), digest, schnorrSig), nil
+}
@@ -0,0 +1,645 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcutil/psbt"
+)
+
+const (
+	//MultisigP2SH legacy multisig, keys are derived at non-standard m/45'/<account>
+	MultisigP2SH = "p2sh"
+	//MultisigP2WSH native segwit multisig
+	MultisigP2WSH = "p2wsh"
+	//MultisigP2SHP2WSH segwit multisig nested in p2sh
+	MultisigP2SHP2WSH = "p2sh-p2wsh"
+
+	//maxMultisigKeys p2sh redeem script must not exceed 520 bytes
+	maxMultisigKeys = 15
+)
+
+//multisigScript scripts of multisig address
+type multisigScript struct {
+	address       btcutil.Address
+	pkScript      []byte
+	redeemScript  []byte
+	witnessScript []byte
+	pubKeys       [][]byte
+}
+
+func (info *MultisigInfo) check() error {
+	if len(info.XPubs) == 0 || len(info.XPubs) > maxMultisigKeys {
+		return fmt.Errorf("multisig needs 1 to %d xpubs, got %d", maxMultisigKeys, len(info.XPubs))
+	}
+
+	if info.Required < 1 || info.Required > len(info.XPubs) {
+		return fmt.Errorf("multisig required %d out of range [1, %d]", info.Required, len(info.XPubs))
+	}
+
+	return nil
+}
+
+//getMultisigScript derive sorted multisig script of xpubs at change/index
+func getMultisigScript(info *MultisigInfo, change, index int) (*multisigScript, error) {
+	if err := info.check(); err != nil {
+		return nil, err
+	}
+
+	params, err := hdwallet.GetNetParams(info.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeys := make([][]byte, 0, len(info.XPubs))
+	for _, xpub := range info.XPubs {
+		key, err := hdkeychain.NewKeyFromString(xpub)
+		if err != nil {
+			return nil, fmt.Errorf("parse xpub %s: %v", xpub, err)
+		}
+
+		if !key.IsForNet(params) {
+			return nil, fmt.Errorf("xpub %s is not for network %s", xpub, params.Name)
+		}
+
+		if key.IsPrivate() {
+			return nil, errors.New("multisig wallet only accepts extended public keys")
+		}
+
+		key, err = key.Child(uint32(change))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = key.Child(uint32(index))
+		if err != nil {
+			return nil, err
+		}
+
+		pubKey, err := key.ECPubKey()
+		if err != nil {
+			return nil, err
+		}
+
+		pubKeys = append(pubKeys, pubKey.SerializeCompressed())
+	}
+
+	//BIP-67: sort public keys lexicographically
+	sort.Slice(pubKeys, func(i, j int) bool {
+		return bytes.Compare(pubKeys[i], pubKeys[j]) < 0
+	})
+
+	addrPubKeys := make([]*btcutil.AddressPubKey, 0, len(pubKeys))
+	for _, pk := range pubKeys {
+		addrPubKey, err := btcutil.NewAddressPubKey(pk, params)
+		if err != nil {
+			return nil, err
+		}
+
+		addrPubKeys = append(addrPubKeys, addrPubKey)
+	}
+
+	script, err := txscript.MultiSigScript(addrPubKeys, info.Required)
+	if err != nil {
+		return nil, fmt.Errorf("multisig script: %v", err)
+	}
+
+	ms := &multisigScript{
+		pubKeys: pubKeys,
+	}
+
+	switch info.ScriptType {
+	case MultisigP2SH:
+		ms.redeemScript = script
+		ms.address, err = btcutil.NewAddressScriptHash(script, params)
+	case MultisigP2WSH:
+		ms.witnessScript = script
+		scriptHash := sha256.Sum256(script)
+		ms.address, err = btcutil.NewAddressWitnessScriptHash(scriptHash[:], params)
+	case MultisigP2SHP2WSH:
+		ms.witnessScript = script
+		scriptHash := sha256.Sum256(script)
+		ms.redeemScript, err = txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(scriptHash[:]).Script()
+		if err != nil {
+			return nil, err
+		}
+		ms.address, err = btcutil.NewAddressScriptHash(ms.redeemScript, params)
+	default:
+		return nil, fmt.Errorf("multisig script type %s is not support", info.ScriptType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ms.pkScript, err = txscript.PayToAddrScript(ms.address)
+	if err != nil {
+		return nil, err
+	}
+
+	return ms, nil
+}
+
+//parseMultisigScript get public keys and required signatures of multisig script
+func parseMultisigScript(script []byte) ([][]byte, int, error) {
+	numPubKeys, numSigs, err := txscript.CalcMultiSigStats(script)
+	if err != nil {
+		return nil, 0, fmt.Errorf("not a multisig script: %v", err)
+	}
+
+	pubKeys, err := txscript.PushedData(script)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(pubKeys) != numPubKeys {
+		return nil, 0, fmt.Errorf("multisig script has %d keys, expect %d", len(pubKeys), numPubKeys)
+	}
+
+	return pubKeys, numSigs, nil
+}
+
+func containsKey(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasPartialSig(pin *psbt.PInput, pubKey []byte) bool {
+	for _, ps := range pin.PartialSigs {
+		if bytes.Equal(ps.PubKey, pubKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//psbtPrevOut get the spent output of psbt input
+func psbtPrevOut(packet *psbt.Packet, i int) (*wire.TxOut, error) {
+	pin := &packet.Inputs[i]
+	if pin.WitnessUtxo != nil {
+		return pin.WitnessUtxo, nil
+	}
+
+	if pin.NonWitnessUtxo != nil {
+		vout := packet.UnsignedTx.TxIn[i].PreviousOutPoint.Index
+		if int(vout) >= len(pin.NonWitnessUtxo.TxOut) {
+			return nil, fmt.Errorf("input %d spends missing output %d", i, vout)
+		}
+
+		return pin.NonWitnessUtxo.TxOut[vout], nil
+	}
+
+	return nil, fmt.Errorf("input %d has no utxo", i)
+}
+
+func decodePSBT(b64 string) (*psbt.Packet, error) {
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(b64), true)
+	if err != nil {
+		return nil, fmt.Errorf("decode psbt: %v", err)
+	}
+
+	return packet, nil
+}
+
+//GetMultisigXPub get BIP-48 account xpub shared with other cosigners, p2sh uses the
+//non-standard path m/45'/<account> which is not compatible with BIP-45 wallets
+func GetMultisigXPub(mnemonic, coinType, network string, account int, scriptType string) (string, error) {
+	wallet, err := hdwallet.NewWalletWithNetwork(mnemonic, coinType, network)
+	if err != nil {
+		return "", err
+	}
+
+	return wallet.GetMultisigXPub(coinType, account, scriptType)
+}
+
+//GetMultisigPrivateKey get hex private key of cosigner used to sign multisig psbt
+func GetMultisigPrivateKey(mnemonic, coinType, network string, account int, scriptType string, change, index int) (string, error) {
+	wallet, err := hdwallet.NewWalletWithNetwork(mnemonic, coinType, network)
+	if err != nil {
+		return "", err
+	}
+
+	return wallet.GetMultisigPrivateKey(coinType, account, scriptType, change, index)
+}
+
+//CreateMultisigAddress create multisig address at change/index, info is json of MultisigInfo
+func CreateMultisigAddress(info string, change, index int) (string, error) {
+	var in MultisigInfo
+	err := json.Unmarshal([]byte(info), &in)
+	if err != nil {
+		return "", err
+	}
+
+	ms, err := getMultisigScript(&in, change, index)
+	if err != nil {
+		return "", err
+	}
+
+	pubKeys := make([]string, 0, len(ms.pubKeys))
+	for _, pk := range ms.pubKeys {
+		pubKeys = append(pubKeys, hex.EncodeToString(pk))
+	}
+
+	obj := &MultisigAddress{
+		Address:       ms.address.EncodeAddress(),
+		ScriptType:    in.ScriptType,
+		RedeemScript:  hex.EncodeToString(ms.redeemScript),
+		WitnessScript: hex.EncodeToString(ms.witnessScript),
+		PubKeys:       pubKeys,
+		Change:        change,
+		Index:         index,
+	}
+
+	strObj, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	return string(strObj), nil
+}
+
+//CreateMultisigPSBT build unsigned psbt(base64) spending multisig utxos
+func CreateMultisigPSBT(input string) (string, error) {
+	var in MultisigTxInput
+	err := json.Unmarshal([]byte(input), &in)
+	if err != nil {
+		return "", err
+	}
+
+	if len(in.Utxos) == 0 {
+		return "", errors.New("multisig psbt needs utxos")
+	}
+
+	params, err := hdwallet.GetNetParams(in.Wallet.Network)
+	if err != nil {
+		return "", err
+	}
+
+	redemTx := wire.NewMsgTx(wire.TxVersion)
+
+	changeAmount := in.getChangeAmount()
+	if changeAmount < 0 {
+		return "", fmt.Errorf("insufficient amount of utxos, need %d more", -changeAmount)
+	}
+
+	dust, err := addressDustThreshold(in.ChangeAddress, in.DustRelayFee, params)
+	if err != nil {
+		return "", fmt.Errorf("change address: %v", err)
+	}
+
+	//change below dust threshold is left to miner
+	if changeAmount >= dust {
+		if err := checkBTCAddress(in.ChangeAddress, params); err != nil {
+			return "", fmt.Errorf("change address: %v", err)
+		}
+
+		if err := addTxOut(redemTx, in.ChangeAddress, changeAmount, params); err != nil {
+			return "", err
+		}
+	}
+
+	for _, v := range in.To {
+		if err := checkBTCAddress(v.To, params); err != nil {
+			return "", err
+		}
+
+		if err := addTxOut(redemTx, v.To, v.Satoshis, params); err != nil {
+			return "", err
+		}
+	}
+
+	for _, txin := range in.Utxos {
+		hash, err := chainhash.NewHashFromStr(txin.TxID)
+		if err != nil {
+			return "", fmt.Errorf("could not get hash from transaction ID: %v", err)
+		}
+
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, uint32(txin.OutputIndex)), nil, nil)
+		txIn.Sequence = CurrentTxInSequenceNum
+		redemTx.AddTxIn(txIn)
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(redemTx)
+	if err != nil {
+		return "", fmt.Errorf("new psbt: %v", err)
+	}
+
+	for i, txin := range in.Utxos {
+		ms, err := getMultisigScript(&in.Wallet, txin.Change, txin.Index)
+		if err != nil {
+			return "", err
+		}
+
+		pin := &packet.Inputs[i]
+		pin.RedeemScript = ms.redeemScript
+		pin.WitnessScript = ms.witnessScript
+		pin.SighashType = txscript.SigHashAll
+
+		//BIP-174: non-witness input must carry the whole previous transaction
+		if in.Wallet.ScriptType == MultisigP2SH {
+			if txin.PrevTx == "" {
+				return "", fmt.Errorf("prevtx of p2sh input %d is required", i)
+			}
+
+			prevBytes, err := hex.DecodeString(txin.PrevTx)
+			if err != nil {
+				return "", fmt.Errorf("decode prevtx of input %d: %v", i, err)
+			}
+
+			prevTx := wire.NewMsgTx(wire.TxVersion)
+			if err := prevTx.Deserialize(bytes.NewReader(prevBytes)); err != nil {
+				return "", fmt.Errorf("deserialize prevtx of input %d: %v", i, err)
+			}
+
+			if prevTx.TxHash() != redemTx.TxIn[i].PreviousOutPoint.Hash {
+				return "", fmt.Errorf("prevtx of input %d does not match txid %s", i, txin.TxID)
+			}
+
+			pin.NonWitnessUtxo = prevTx
+		} else {
+			pin.WitnessUtxo = wire.NewTxOut(txin.Satoshis, ms.pkScript)
+		}
+
+		prevOut, err := psbtPrevOut(packet, i)
+		if err != nil {
+			return "", err
+		}
+
+		if !bytes.Equal(prevOut.PkScript, ms.pkScript) {
+			return "", fmt.Errorf("input %d is not paid to multisig address %s", i, ms.address.EncodeAddress())
+		}
+
+		if prevOut.Value != txin.Satoshis {
+			return "", fmt.Errorf("input %d has %d satoshis in prevtx, not %d", i, prevOut.Value, txin.Satoshis)
+		}
+	}
+
+	return packet.B64Encode()
+}
+
+//SignMultisigPSBT add partial signatures of the given private keys, input is json of PSBTSignInput,
+//it fails if no key adds a signature
+func SignMultisigPSBT(input string) (string, error) {
+	return SignMultisigPSBTWithSigner(input, nil)
+}
+
+//SignMultisigPSBTWithSigner like SignMultisigPSBT, keys of PSBTSignInput.KeyIDs are signed by signer
+func SignMultisigPSBTWithSigner(input string, signer hdwallet.Signer) (string, error) {
+	var in PSBTSignInput
+	err := json.Unmarshal([]byte(input), &in)
+	if err != nil {
+		return "", err
+	}
+
+	if len(in.KeyIDs) > 0 && signer == nil {
+		return "", errors.New("signer is nil")
+	}
+
+	packet, err := decodePSBT(in.PSBT)
+	if err != nil {
+		return "", err
+	}
+
+	//private keys sign in memory with their index as key id
+	keySigner := hdwallet.NewKeySigner()
+	keys := make([]psbtKey, 0, len(in.Privates)+len(in.KeyIDs))
+	for i, private := range in.Privates {
+		key, err := hdwallet.HexToECDSAPrivateKey(private)
+		if err != nil {
+			return "", err
+		}
+
+		keyID := fmt.Sprintf("%d", i)
+		keySigner.AddKey(keyID, key)
+		keys = append(keys, psbtKey{signer: keySigner, keyID: keyID})
+	}
+
+	for _, keyID := range in.KeyIDs {
+		keys = append(keys, psbtKey{signer: signer, keyID: keyID})
+	}
+
+	for i := range keys {
+		keys[i].pubKey, err = keys[i].signer.PublicKey(keys[i].keyID)
+		if err != nil {
+			return "", fmt.Errorf("public key of %q: %v", keys[i].keyID, err)
+		}
+	}
+
+	tx := packet.UnsignedTx
+	txSigHashes := txscript.NewTxSigHashes(tx)
+
+	added := 0
+	for i := range packet.Inputs {
+		pin := &packet.Inputs[i]
+
+		script := pin.WitnessScript
+		if script == nil {
+			script = pin.RedeemScript
+		}
+
+		pubKeys, _, err := parseMultisigScript(script)
+		if err != nil {
+			return "", fmt.Errorf("input %d: %v", i, err)
+		}
+
+		hashType := pin.SighashType
+		if hashType == 0 {
+			hashType = txscript.SigHashAll
+		}
+
+		for _, key := range keys {
+			if !containsKey(pubKeys, key.pubKey) || hasPartialSig(pin, key.pubKey) {
+				continue
+			}
+
+			var sig []byte
+			if pin.WitnessScript != nil {
+				prevOut, err := psbtPrevOut(packet, i)
+				if err != nil {
+					return "", err
+				}
+
+				sig, err = rawTxInWitnessSignature(tx, txSigHashes, i, prevOut.Value, pin.WitnessScript, hashType, key.signer, key.keyID)
+				if err != nil {
+					return "", fmt.Errorf("could not generate signature of input %d: %v", i, err)
+				}
+			} else {
+				sig, err = rawTxInSignature(tx, i, pin.RedeemScript, hashType, key.signer, key.keyID)
+				if err != nil {
+					return "", fmt.Errorf("could not generate signature of input %d: %v", i, err)
+				}
+			}
+
+			pin.PartialSigs = append(pin.PartialSigs, &psbt.PartialSig{
+				PubKey:    key.pubKey,
+				Signature: sig,
+			})
+			added++
+		}
+	}
+
+	//wrong or already used keys would give back the psbt unchanged
+	if added == 0 {
+		return "", errors.New("no partial signature added, keys are not cosigners of unsigned inputs")
+	}
+
+	return packet.B64Encode()
+}
+
+//psbtKey cosigner key of psbt, signed by signer with keyID
+type psbtKey struct {
+	signer hdwallet.Signer
+	keyID  string
+	pubKey []byte
+}
+
+//CombineMultisigPSBT merge partial signatures of cosigners, psbts is json array of base64 psbt
+func CombineMultisigPSBT(psbts string) (string, error) {
+	list := make([]string, 0)
+	err := json.Unmarshal([]byte(psbts), &list)
+	if err != nil {
+		return "", err
+	}
+
+	if len(list) == 0 {
+		return "", errors.New("no psbt to combine")
+	}
+
+	packet, err := decodePSBT(list[0])
+	if err != nil {
+		return "", err
+	}
+
+	for _, str := range list[1:] {
+		other, err := decodePSBT(str)
+		if err != nil {
+			return "", err
+		}
+
+		if other.UnsignedTx.TxHash() != packet.UnsignedTx.TxHash() {
+			return "", errors.New("could not combine psbt of different transactions")
+		}
+
+		for i := range other.Inputs {
+			for _, ps := range other.Inputs[i].PartialSigs {
+				if !hasPartialSig(&packet.Inputs[i], ps.PubKey) {
+					packet.Inputs[i].PartialSigs = append(packet.Inputs[i].PartialSigs, ps)
+				}
+			}
+		}
+	}
+
+	return packet.B64Encode()
+}
+
+//FinalizeMultisigPSBT assemble scriptSig and witness from partial signatures
+func FinalizeMultisigPSBT(psbtStr string) (*TransactionBTC, error) {
+	packet, err := decodePSBT(psbtStr)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := packet.UnsignedTx.Copy()
+
+	for i := range packet.Inputs {
+		pin := &packet.Inputs[i]
+
+		script := pin.WitnessScript
+		if script == nil {
+			script = pin.RedeemScript
+		}
+
+		pubKeys, required, err := parseMultisigScript(script)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+
+		//signatures must follow the order of public keys in script
+		sigs := make([][]byte, 0, required)
+		for _, pk := range pubKeys {
+			for _, ps := range pin.PartialSigs {
+				if bytes.Equal(ps.PubKey, pk) {
+					sigs = append(sigs, ps.Signature)
+					break
+				}
+			}
+
+			if len(sigs) == required {
+				break
+			}
+		}
+
+		if len(sigs) < required {
+			return nil, fmt.Errorf("input %d has %d of %d signatures", i, len(sigs), required)
+		}
+
+		if pin.WitnessScript != nil {
+			//empty item for the extra pop of OP_CHECKMULTISIG
+			witness := wire.TxWitness{[]byte{}}
+			witness = append(witness, sigs...)
+			witness = append(witness, pin.WitnessScript)
+			tx.TxIn[i].Witness = witness
+
+			if pin.RedeemScript != nil {
+				scriptsig, err := txscript.NewScriptBuilder().AddData(pin.RedeemScript).Script()
+				if err != nil {
+					return nil, err
+				}
+
+				tx.TxIn[i].SignatureScript = scriptsig
+			}
+		} else {
+			b := txscript.NewScriptBuilder()
+			b.AddOp(txscript.OP_0)
+			for _, sig := range sigs {
+				b.AddData(sig)
+			}
+			b.AddData(pin.RedeemScript)
+
+			scriptsig, err := b.Script()
+			if err != nil {
+				return nil, err
+			}
+
+			tx.TxIn[i].SignatureScript = scriptsig
+		}
+	}
+
+	prevOuts := make([]*wire.TxOut, 0, len(tx.TxIn))
+	for i := range tx.TxIn {
+		prevOut, err := psbtPrevOut(packet, i)
+		if err != nil {
+			return nil, err
+		}
+
+		prevOuts = append(prevOuts, prevOut)
+	}
+
+	if err := verifyBTCTx(tx, prevOuts); err != nil {
+		return nil, err
+	}
+
+	return &TransactionBTC{
+		HexTx: txToHex(tx),
+		TxID:  tx.TxHash().String(),
+	}, nil
+}
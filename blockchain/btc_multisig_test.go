@@ -0,0 +1,162 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMultisigPSBT(t *testing.T) {
+	for _, scriptType := range []string{MultisigP2SH, MultisigP2WSH, MultisigP2SHP2WSH} {
+		//three cosigners share one mnemonic with different accounts
+		xpubs := make([]string, 0)
+		privates := make([]string, 0)
+		for account := 0; account < 3; account++ {
+			xpub, err := GetMultisigXPub(testMnemonic, "BTC", "mainnet", account, scriptType)
+			if err != nil {
+				t.Fatalf("GetMultisigXPub: %v\n", err)
+			}
+			xpubs = append(xpubs, xpub)
+
+			private, err := GetMultisigPrivateKey(testMnemonic, "BTC", "mainnet", account, scriptType, 0, 0)
+			if err != nil {
+				t.Fatalf("GetMultisigPrivateKey: %v\n", err)
+			}
+			privates = append(privates, private)
+		}
+
+		for i := 1; i < len(xpubs); i++ {
+			if xpubs[i] == xpubs[0] {
+				t.Fatalf("%s accounts should give distinct cosigners\n", scriptType)
+			}
+		}
+
+		info := MultisigInfo{
+			XPubs:      xpubs,
+			Required:   (len(xpubs) + 1) / 2,
+			ScriptType: scriptType,
+		}
+		infoBytes, _ := json.Marshal(info)
+
+		addr, err := CreateMultisigAddress(string(infoBytes), 0, 0)
+		if err != nil {
+			t.Fatalf("CreateMultisigAddress: %v\n", err)
+		}
+		fmt.Printf("%s address: %v\n", scriptType, addr)
+
+		var msAddr MultisigAddress
+		json.Unmarshal([]byte(addr), &msAddr)
+
+		//previous transaction funding the multisig address
+		prevTx := wire.NewMsgTx(wire.TxVersion)
+		prevHash, _ := chainhash.NewHashFromStr("4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa")
+		prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+		pkScript, _ := getPayToAddrScript(msAddr.Address, &chaincfg.MainNetParams)
+		prevTx.AddTxOut(wire.NewTxOut(100000, pkScript))
+		var prevBuf bytes.Buffer
+		prevTx.Serialize(&prevBuf)
+
+		input := MultisigTxInput{
+			Wallet: info,
+			Utxos: []MultisigUtxo{{
+				TxID:        prevTx.TxHash().String(),
+				OutputIndex: 0,
+				Satoshis:    100000,
+				PrevTx:      hex.EncodeToString(prevBuf.Bytes()),
+			}},
+			To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 50000}},
+			ChangeAddress: msAddr.Address,
+			Fee:           1000,
+		}
+
+		if scriptType == MultisigP2SH {
+			bad := input
+			bad.Utxos = []MultisigUtxo{input.Utxos[0]}
+			bad.Utxos[0].PrevTx = ""
+			badBytes, _ := json.Marshal(bad)
+			if _, err := CreateMultisigPSBT(string(badBytes)); err == nil {
+				t.Errorf("p2sh input without prevtx should fail\n")
+			}
+
+			bad.Utxos[0].PrevTx = input.Utxos[0].PrevTx
+			bad.Utxos[0].Satoshis = 200000
+			badBytes, _ = json.Marshal(bad)
+			if _, err := CreateMultisigPSBT(string(badBytes)); err == nil {
+				t.Errorf("p2sh input of wrong amount should fail\n")
+			}
+		}
+		inputBytes, _ := json.Marshal(input)
+
+		unsigned, err := CreateMultisigPSBT(string(inputBytes))
+		if err != nil {
+			t.Fatalf("CreateMultisigPSBT: %v\n", err)
+		}
+
+		//each cosigner signs alone, then partial signatures are combined
+		signed := make([]string, 0)
+		for i := 0; i < info.Required; i++ {
+			signBytes, _ := json.Marshal(PSBTSignInput{PSBT: unsigned, Privates: privates[i : i+1]})
+			partial, err := SignMultisigPSBT(string(signBytes))
+			if err != nil {
+				t.Fatalf("SignMultisigPSBT: %v\n", err)
+			}
+			signed = append(signed, partial)
+		}
+
+		//external signer gives the same partial signature as the private key
+		keySigner := hdwallet.NewKeySigner()
+		keySigner.AddHexKey("cosigner 0", privates[0])
+		signer := hdwallet.NewMockSigner(keySigner)
+		signBytes, _ := json.Marshal(PSBTSignInput{PSBT: unsigned, KeyIDs: []string{"cosigner 0"}})
+		partial, err := SignMultisigPSBTWithSigner(string(signBytes), signer)
+		if err != nil {
+			t.Fatalf("SignMultisigPSBTWithSigner: %v\n", err)
+		}
+
+		if partial != signed[0] || signer.SignCount() != 1 || signer.SignedKeyID(0) != "cosigner 0" {
+			t.Errorf("%s signer partial signature differs: %v\n", scriptType, partial)
+		}
+
+		if _, err := SignMultisigPSBTWithSigner(string(signBytes), nil); err == nil {
+			t.Errorf("key ids without signer should fail\n")
+		}
+
+		//signing again or with a key of no cosigner adds nothing
+		signBytes, _ = json.Marshal(PSBTSignInput{PSBT: signed[0], Privates: privates[0:1]})
+		if _, err := SignMultisigPSBT(string(signBytes)); err == nil {
+			t.Errorf("%s signing twice should fail\n", scriptType)
+		}
+
+		signBytes, _ = json.Marshal(PSBTSignInput{PSBT: unsigned, Privates: []string{"6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"}})
+		if _, err := SignMultisigPSBT(string(signBytes)); err == nil {
+			t.Errorf("%s signing with another key should fail\n", scriptType)
+		}
+
+		if _, err := FinalizeMultisigPSBT(unsigned); err == nil {
+			t.Errorf("finalize unsigned psbt should fail\n")
+		}
+
+		signedBytes, _ := json.Marshal(signed)
+		combined, err := CombineMultisigPSBT(string(signedBytes))
+		if err != nil {
+			t.Fatalf("CombineMultisigPSBT: %v\n", err)
+		}
+
+		tx, err := FinalizeMultisigPSBT(combined)
+		if err != nil {
+			t.Fatalf("FinalizeMultisigPSBT: %v\n", err)
+		}
+
+		fmt.Printf("%s tx: %v %v\n", scriptType, tx.TxID, tx.HexTx)
+	}
+}
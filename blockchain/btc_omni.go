@@ -0,0 +1,401 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//omni layer transaction types
+const (
+	OmniTypeSimpleSend             uint16 = 0
+	OmniTypeSendAll                uint16 = 4
+	OmniTypeDExSellOffer           uint16 = 20
+	OmniTypeDExAccept              uint16 = 22
+	OmniTypeMetaDExTrade           uint16 = 25
+	OmniTypeMetaDExCancelPrice     uint16 = 26
+	OmniTypeMetaDExCancelPair      uint16 = 27
+	OmniTypeMetaDExCancelEcosystem uint16 = 28
+)
+
+//omni ecosystems
+const (
+	OmniEcosystemMain uint8 = 1
+	OmniEcosystemTest uint8 = 2
+)
+
+//omniMarker prefix of class C omni payload
+const omniMarker = "omni"
+
+//omniDivisibleDecimals decimals of divisible omni property
+const omniDivisibleDecimals = 8
+
+//hasReference reference output receives tokens of simple send, send all and DEx accept
+func (p *OmniPayload) hasReference() bool {
+	switch p.Type {
+	case OmniTypeSimpleSend, OmniTypeSendAll, OmniTypeDExAccept:
+		return true
+	}
+
+	return false
+}
+
+func (p *OmniPayload) checkEcosystem() error {
+	if p.Ecosystem != OmniEcosystemMain && p.Ecosystem != OmniEcosystemTest {
+		return fmt.Errorf("omni ecosystem should be 1 or 2, got %d", p.Ecosystem)
+	}
+
+	return nil
+}
+
+func (p *OmniPayload) check() error {
+	switch p.Type {
+	case OmniTypeSimpleSend, OmniTypeDExAccept:
+		if p.PropertyID == 0 || p.Amount <= 0 {
+			return fmt.Errorf("omni type %d needs property and positive amount", p.Type)
+		}
+	case OmniTypeSendAll, OmniTypeMetaDExCancelEcosystem:
+		return p.checkEcosystem()
+	case OmniTypeDExSellOffer:
+		if p.PropertyID == 0 || p.Action < 1 || p.Action > 3 {
+			return fmt.Errorf("omni DEx sell offer needs property and action 1, 2 or 3")
+		}
+
+		//cancel carries no amounts
+		if p.Action != 3 && (p.Amount <= 0 || p.DesiredAmount <= 0) {
+			return fmt.Errorf("omni DEx sell offer needs positive amount and desired amount")
+		}
+	case OmniTypeMetaDExTrade, OmniTypeMetaDExCancelPrice:
+		if p.PropertyID == 0 || p.DesiredPropertyID == 0 || p.Amount <= 0 || p.DesiredAmount <= 0 {
+			return fmt.Errorf("omni type %d needs properties and positive amounts", p.Type)
+		}
+	case OmniTypeMetaDExCancelPair:
+		if p.PropertyID == 0 || p.DesiredPropertyID == 0 {
+			return fmt.Errorf("omni type %d needs properties", p.Type)
+		}
+	default:
+		return fmt.Errorf("omni type %d is not support", p.Type)
+	}
+
+	return nil
+}
+
+//encode class C payload, field layout follows Omni Core
+func (p *OmniPayload) encode() ([]byte, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+
+	version := p.Version
+	if p.Type == OmniTypeDExSellOffer && version == 0 {
+		//Omni Core creates sell offers of version 1 which carries the action
+		version = 1
+	}
+
+	fields := []interface{}{version, p.Type}
+	switch p.Type {
+	case OmniTypeSimpleSend, OmniTypeDExAccept:
+		fields = append(fields, p.PropertyID, p.Amount)
+	case OmniTypeSendAll, OmniTypeMetaDExCancelEcosystem:
+		fields = append(fields, p.Ecosystem)
+	case OmniTypeDExSellOffer:
+		fields = append(fields, p.PropertyID, p.Amount, p.DesiredAmount, p.PaymentWindow, p.MinFee, p.Action)
+	case OmniTypeMetaDExTrade, OmniTypeMetaDExCancelPrice:
+		fields = append(fields, p.PropertyID, p.Amount, p.DesiredPropertyID, p.DesiredAmount)
+	case OmniTypeMetaDExCancelPair:
+		fields = append(fields, p.PropertyID, p.DesiredPropertyID)
+	}
+
+	buf := bytes.NewBufferString(omniMarker)
+	for _, field := range fields {
+		binary.Write(buf, binary.BigEndian, field)
+	}
+
+	return buf.Bytes(), nil
+}
+
+//decodeOmniData decode omni payload of OP_RETURN data
+func decodeOmniData(data []byte) (*OmniPayload, error) {
+	if len(data) < 8 || string(data[:4]) != omniMarker {
+		return nil, errors.New("not an omni payload")
+	}
+
+	payload := &OmniPayload{
+		Version: binary.BigEndian.Uint16(data[4:6]),
+		Type:    binary.BigEndian.Uint16(data[6:8]),
+	}
+
+	var fields []interface{}
+	switch payload.Type {
+	case OmniTypeSimpleSend, OmniTypeDExAccept:
+		fields = []interface{}{&payload.PropertyID, &payload.Amount}
+	case OmniTypeSendAll, OmniTypeMetaDExCancelEcosystem:
+		fields = []interface{}{&payload.Ecosystem}
+	case OmniTypeDExSellOffer:
+		fields = []interface{}{&payload.PropertyID, &payload.Amount, &payload.DesiredAmount,
+			&payload.PaymentWindow, &payload.MinFee}
+		//version 0 has no action
+		if payload.Version > 0 {
+			fields = append(fields, &payload.Action)
+		}
+	case OmniTypeMetaDExTrade, OmniTypeMetaDExCancelPrice:
+		fields = []interface{}{&payload.PropertyID, &payload.Amount, &payload.DesiredPropertyID, &payload.DesiredAmount}
+	case OmniTypeMetaDExCancelPair:
+		fields = []interface{}{&payload.PropertyID, &payload.DesiredPropertyID}
+	default:
+		//fields of other types are not decoded
+		return payload, nil
+	}
+
+	reader := bytes.NewReader(data[8:])
+	for _, field := range fields {
+		if err := binary.Read(reader, binary.BigEndian, field); err != nil {
+			return nil, fmt.Errorf("omni type %d payload too short: %d bytes", payload.Type, len(data))
+		}
+	}
+
+	return payload, nil
+}
+
+func getOmniTxOut(payload *OmniPayload) (*wire.TxOut, error) {
+	data, err := payload.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	b := txscript.NewScriptBuilder()
+	b.AddOp(txscript.OP_RETURN)
+	b.AddData(data)
+
+	sigscript, err := b.Script()
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(0, sigscript), nil
+}
+
+//OmniAmount convert token amount like "1.5" to willets, divisible property has 8 decimals
+func OmniAmount(amount string, divisible bool) (int64, error) {
+	amount = strings.TrimSpace(amount)
+
+	intPart, fracPart := amount, ""
+	if dot := strings.Index(amount, "."); dot >= 0 {
+		intPart, fracPart = amount[:dot], amount[dot+1:]
+	}
+
+	if !divisible && strings.Trim(fracPart, "0") != "" {
+		return 0, fmt.Errorf("indivisible omni amount %s should be integer", amount)
+	}
+
+	if divisible {
+		if len(fracPart) > omniDivisibleDecimals {
+			return 0, fmt.Errorf("omni amount %s has more than %d decimals", amount, omniDivisibleDecimals)
+		}
+		intPart += fracPart + strings.Repeat("0", omniDivisibleDecimals-len(fracPart))
+	}
+
+	if intPart == "" || strings.ContainsAny(intPart, "+-") {
+		return 0, fmt.Errorf("invalid omni amount %s", amount)
+	}
+
+	willets, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid omni amount %s: %v", amount, err)
+	}
+
+	if willets <= 0 {
+		return 0, fmt.Errorf("omni amount %s should be positive", amount)
+	}
+
+	return willets, nil
+}
+
+//FormatOmniAmount convert willets to token amount, divisible property has 8 decimals
+func FormatOmniAmount(willets int64, divisible bool) string {
+	if !divisible {
+		return strconv.FormatInt(willets, 10)
+	}
+
+	return fmt.Sprintf("%d.%08d", willets/1e8, willets%1e8)
+}
+
+//CreateOmniPayload encode json of OmniPayload into hex of class C payload
+func CreateOmniPayload(payload string) (string, error) {
+	var p OmniPayload
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return "", err
+	}
+
+	data, err := p.encode()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+//DecodeOmniPayload decode hex of class C payload or OP_RETURN pkscript, result is json of OmniPayload
+func DecodeOmniPayload(data string) (string, error) {
+	raw, err := hex.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+
+	if len(raw) > 0 && raw[0] == txscript.OP_RETURN {
+		pushes, err := txscript.PushedData(raw)
+		if err != nil || len(pushes) == 0 {
+			return "", errors.New("OP_RETURN script carries no data")
+		}
+		raw = pushes[0]
+	}
+
+	payload, err := decodeOmniData(raw)
+	if err != nil {
+		return "", err
+	}
+
+	strPayload, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return string(strPayload), nil
+}
+
+//buildOmniTx outputs are ordered as change, OP_RETURN and reference,
+//omni reads the last output as reference so change can never receive the tokens
+func buildOmniTx(input OmniSendInput, signer hdwallet.Signer) (*wire.MsgTx, error) {
+	params, err := hdwallet.GetNetParams(input.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.Utxos) == 0 {
+		return nil, errors.New("omni send needs utxos of the sender")
+	}
+
+	//omni takes the sender from inputs, mixed addresses make the sender ambiguous
+	sender := input.Utxos[0].Address
+	for _, utxo := range input.Utxos {
+		if utxo.Address != sender {
+			return nil, fmt.Errorf("utxo address %s is not the sender %s", utxo.Address, sender)
+		}
+	}
+
+	changeAddress := input.ChangeAddress
+	if changeAddress == "" {
+		changeAddress = sender
+	}
+
+	if err := checkBTCAddress(input.To, params); err != nil {
+		return nil, err
+	}
+
+	if err := checkBTCAddress(changeAddress, params); err != nil {
+		return nil, fmt.Errorf("change address: %v", err)
+	}
+
+	if input.To == changeAddress || input.To == sender {
+		return nil, fmt.Errorf("reference recipient %s should not be the sender or change address", input.To)
+	}
+
+	amount, err := OmniAmount(input.Amount, input.Divisible)
+	if err != nil {
+		return nil, err
+	}
+
+	omniOut, err := getOmniTxOut(&OmniPayload{
+		Type:       OmniTypeSimpleSend,
+		PropertyID: input.PropertyID,
+		Amount:     amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dust, err := addressDustThreshold(input.To, input.DustRelayFee, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Dust != 0 {
+		if input.Dust < dust {
+			return nil, fmt.Errorf("reference output %d is below dust threshold %d", input.Dust, dust)
+		}
+		dust = input.Dust
+	}
+
+	fromAmount := int64(0)
+	for _, utxo := range input.Utxos {
+		fromAmount += utxo.Satoshis
+	}
+
+	changeAmount := fromAmount - input.Fee - dust
+	if input.Fee < 0 || dust < 0 || changeAmount < 0 {
+		return nil, fmt.Errorf("utxos of %d satoshis can not pay fee %d and reference %d", fromAmount, input.Fee, dust)
+	}
+
+	redemTx := newBTCTx(input.Utxos, input.LockTime)
+
+	changeDust, err := addressDustThreshold(changeAddress, input.DustRelayFee, params)
+	if err != nil {
+		return nil, fmt.Errorf("change address: %v", err)
+	}
+
+	//change below dust threshold goes to miner
+	if changeAmount >= changeDust {
+		if err := addTxOut(redemTx, changeAddress, changeAmount, params); err != nil {
+			return nil, err
+		}
+	}
+	redemTx.AddTxOut(omniOut)
+	if err := addTxOut(redemTx, input.To, dust, params); err != nil {
+		return nil, err
+	}
+
+	if err := signBTCTx(redemTx, input.Utxos, signer, params); err != nil {
+		return nil, err
+	}
+
+	return redemTx, nil
+}
+
+//TransferOmni build and sign omni simple send, like USDT(property 31, divisible), input is json of OmniSendInput
+func TransferOmni(omni string) (*TransactionBTC, error) {
+	var input OmniSendInput
+	err := json.Unmarshal([]byte(omni), &input)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := buildOmniTx(input, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//whatever miner gets beyond Fee is the dropped change
+	droppedChange := -input.Fee
+	for _, utxo := range input.Utxos {
+		droppedChange += utxo.Satoshis
+	}
+	for _, out := range tx.TxOut {
+		droppedChange -= out.Value
+	}
+
+	return &TransactionBTC{
+		HexTx:         txToHex(tx),
+		TxID:          tx.TxHash().String(),
+		DroppedChange: droppedChange,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+)
+
+func TestOmniPayload(t *testing.T) {
+	//payload vectors of Omni Core create_payload_tests
+	cases := []struct {
+		payload OmniPayload
+		hexData string
+	}{
+		{OmniPayload{Type: OmniTypeSimpleSend, PropertyID: 1, Amount: 100000000},
+			"00000000000000010000000005f5e100"},
+		{OmniPayload{Type: OmniTypeSendAll, Ecosystem: OmniEcosystemTest},
+			"0000000402"},
+		{OmniPayload{Version: 1, Type: OmniTypeDExSellOffer, PropertyID: 1, Amount: 100000000, DesiredAmount: 20000000,
+			PaymentWindow: 10, MinFee: 10000, Action: 1},
+			"00010014000000010000000005f5e1000000000001312d000a000000000000271001"},
+		{OmniPayload{Type: OmniTypeDExAccept, PropertyID: 1, Amount: 130000000},
+			"00000016000000010000000007bfa480"},
+		{OmniPayload{Type: OmniTypeMetaDExTrade, PropertyID: 1, Amount: 250000000, DesiredPropertyID: 31, DesiredAmount: 50000000},
+			"0000001900000001000000000ee6b2800000001f0000000002faf080"},
+		{OmniPayload{Type: OmniTypeMetaDExCancelPrice, PropertyID: 1, Amount: 250000000, DesiredPropertyID: 31, DesiredAmount: 50000000},
+			"0000001a00000001000000000ee6b2800000001f0000000002faf080"},
+		{OmniPayload{Type: OmniTypeMetaDExCancelPair, PropertyID: 1, DesiredPropertyID: 31},
+			"0000001b000000010000001f"},
+		{OmniPayload{Type: OmniTypeMetaDExCancelEcosystem, Ecosystem: OmniEcosystemMain},
+			"0000001c01"},
+	}
+
+	for _, c := range cases {
+		data, err := c.payload.encode()
+		if err != nil {
+			t.Errorf("encode omni type %d: %v\n", c.payload.Type, err)
+			continue
+		}
+
+		expected := hex.EncodeToString([]byte(omniMarker)) + c.hexData
+		if hex.EncodeToString(data) != expected {
+			t.Errorf("omni type %d payload %x, expected %s\n", c.payload.Type, data, expected)
+		}
+
+		res, err := DecodeOmniPayload(expected)
+		if err != nil {
+			t.Errorf("DecodeOmniPayload type %d: %v\n", c.payload.Type, err)
+			continue
+		}
+
+		var decoded OmniPayload
+		json.Unmarshal([]byte(res), &decoded)
+		if !reflect.DeepEqual(decoded, c.payload) {
+			t.Errorf("decoded omni type %d: %v\n", c.payload.Type, res)
+		}
+	}
+
+	if _, err := (&OmniPayload{Type: OmniTypeSimpleSend, PropertyID: 31}).encode(); err == nil {
+		t.Errorf("simple send without amount should fail\n")
+	}
+}
+
+func TestOmniAmount(t *testing.T) {
+	cases := []struct {
+		amount    string
+		divisible bool
+		willets   int64
+		ok        bool
+	}{
+		{"1.5", true, 150000000, true},
+		{"0.00000001", true, 1, true},
+		{"0.000000001", true, 0, false},
+		{"12", false, 12, true},
+		{"12.0", false, 12, true},
+		{"1.5", false, 0, false},
+		{"-1", true, 0, false},
+		{"0", false, 0, false},
+	}
+
+	for _, c := range cases {
+		willets, err := OmniAmount(c.amount, c.divisible)
+		if (err == nil) != c.ok || willets != c.willets {
+			t.Errorf("OmniAmount %s divisible %v: %d %v\n", c.amount, c.divisible, willets, err)
+		}
+	}
+
+	if FormatOmniAmount(150000000, true) != "1.50000000" || FormatOmniAmount(12, false) != "12" {
+		t.Errorf("FormatOmniAmount mismatch\n")
+	}
+}
+
+func TestTransferOmni(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	from := hdwallet.ToBTC(key.PubKey().SerializeCompressed(), false)
+	to := "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"
+
+	input := OmniSendInput{
+		Utxos: []Utxo{{
+			Address:     from,
+			TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 0,
+			PkScript:    pkScriptHex(from),
+			Satoshis:    100000,
+			Private:     private,
+		}},
+		To:         to,
+		Fee:        1000,
+		PropertyID: 31,
+		Amount:     "1",
+		Divisible:  true,
+	}
+
+	tx, err := buildOmniTx(input, nil)
+	if err != nil {
+		t.Fatalf("buildOmniTx: %v\n", err)
+	}
+
+	if len(tx.TxOut) != 3 {
+		t.Fatalf("omni send should have change, payload and reference outputs, got %d\n", len(tx.TxOut))
+	}
+
+	change, payload, reference := tx.TxOut[0], tx.TxOut[1], tx.TxOut[2]
+	if hex.EncodeToString(change.PkScript) != pkScriptHex(from) ||
+		change.Value != 100000-1000-MinDustOutput {
+		t.Errorf("change output mismatch: %v\n", change)
+	}
+
+	//simple send of 1.00000000 USDT, same layout as Omni Core create_payload_tests
+	if hex.EncodeToString(payload.PkScript) != "6a14"+"6f6d6e69"+"00000000"+"0000001f"+"0000000005f5e100" {
+		t.Errorf("omni payload mismatch: %x\n", payload.PkScript)
+	}
+
+	if hex.EncodeToString(reference.PkScript) != pkScriptHex(to) ||
+		reference.Value != MinDustOutput {
+		t.Errorf("reference output mismatch: %v\n", reference)
+	}
+
+	self := input
+	self.To = from
+	if _, err := buildOmniTx(self, nil); err == nil {
+		t.Errorf("omni send to sender should fail\n")
+	}
+
+	mixed := input
+	mixed.Utxos = append([]Utxo{}, input.Utxos...)
+	mixed.Utxos = append(mixed.Utxos, Utxo{Address: to, Satoshis: 1000})
+	if _, err := buildOmniTx(mixed, nil); err == nil {
+		t.Errorf("omni send from mixed addresses should fail\n")
+	}
+}
@@ -0,0 +1,174 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+//paperWalletAddresses all addresses a wallet may have used for key
+func paperWalletAddresses(key *btcec.PrivateKey, params *chaincfg.Params) ([]PaperWalletAddress, error) {
+	compressed := key.PubKey().SerializeCompressed()
+
+	uncompressedAddr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(key.PubKey().SerializeUncompressed()), params)
+	if err != nil {
+		return nil, err
+	}
+
+	compressedAddr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(compressed), params)
+	if err != nil {
+		return nil, err
+	}
+
+	witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(compressed), params)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := txscript.PayToAddrScript(witnessAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	nestedAddr, err := btcutil.NewAddressScriptHash(program, params)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []struct {
+		addr       btcutil.Address
+		addrType   string
+		compressed bool
+	}{
+		{uncompressedAddr, "p2pkh", false},
+		{compressedAddr, "p2pkh", true},
+		{nestedAddr, "p2sh-p2wpkh", true},
+		{witnessAddr, "p2wpkh", true},
+	}
+
+	addresses := make([]PaperWalletAddress, 0, len(candidates))
+	for _, c := range candidates {
+		pkScript, err := txscript.PayToAddrScript(c.addr)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, PaperWalletAddress{
+			Address:    c.addr.EncodeAddress(),
+			Type:       c.addrType,
+			Compressed: c.compressed,
+			PkScript:   hex.EncodeToString(pkScript),
+		})
+	}
+
+	return addresses, nil
+}
+
+//PaperWalletAddresses candidate addresses of WIF or hex private key on network, result is json array of PaperWalletAddress
+func PaperWalletAddresses(privateKey, network string) (string, error) {
+	params, err := hdwallet.GetNetParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := parsePrivateKey(privateKey, params)
+	if err != nil {
+		return "", err
+	}
+
+	addresses, err := paperWalletAddresses(key, params)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(addresses)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+//sweepPaperWallet sign utxos of candidate addresses with the imported key and sweep them to To
+func sweepPaperWallet(input PaperWalletSweepInput) (*SweepTransaction, error) {
+	params, err := hdwallet.GetNetParams(input.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePrivateKey(input.PrivateKey, params)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := paperWalletAddresses(key, params)
+	if err != nil {
+		return nil, err
+	}
+
+	pkScripts := make(map[string]string)
+	for _, a := range addresses {
+		pkScripts[a.Address] = a.PkScript
+	}
+
+	if len(input.Utxos) == 0 {
+		return nil, errors.New("no utxos to sweep")
+	}
+
+	privateKey := hex.EncodeToString(key.Serialize())
+	utxos := make([]Utxo, 0, len(input.Utxos))
+	for _, utxo := range input.Utxos {
+		addr, err := btcutil.DecodeAddress(utxo.Address, params)
+		if err != nil {
+			return nil, fmt.Errorf("decode address %s: %v", utxo.Address, err)
+		}
+
+		pkScript, ok := pkScripts[addr.EncodeAddress()]
+		if !ok {
+			return nil, fmt.Errorf("address %s does not belong to private key", utxo.Address)
+		}
+
+		utxo.Address = addr.EncodeAddress()
+		utxo.PkScript = pkScript
+		utxo.Private = privateKey
+		utxos = append(utxos, utxo)
+	}
+
+	return sweepBTC(SweepInput{
+		CoinType:     input.CoinType,
+		Network:      input.Network,
+		Utxos:        utxos,
+		To:           input.To,
+		FeeRate:      input.FeeRate,
+		LockTime:     input.LockTime,
+		DustRelayFee: input.DustRelayFee,
+	}, nil)
+}
+
+//SweepPaperWallet move funds of a WIF or hex key into HD wallet, json of PaperWalletSweepInput in and SweepTransaction out
+func SweepPaperWallet(sweep string) (string, error) {
+	var input PaperWalletSweepInput
+	if err := json.Unmarshal([]byte(sweep), &input); err != nil {
+		return "", err
+	}
+
+	result, err := sweepPaperWallet(input)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
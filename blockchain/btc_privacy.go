@@ -0,0 +1,173 @@
+package blockchain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/txsort"
+)
+
+//output orders of BTCTxInput
+const (
+	OutputOrderDefault = ""       //change first, then recipients; change last if an input signs SIGHASH_SINGLE
+	OutputOrderBIP69   = "bip69"  //lexicographic inputs and outputs of BIP-69
+	OutputOrderRandom  = "random" //shuffled inputs and outputs
+)
+
+//randInt uniform random number in [0, n)
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(v.Int64()), nil
+}
+
+//applyPrivacy choose change address, anti-fee-sniping locktime and input order before building
+func (input *BTCTxInput) applyPrivacy(params *chaincfg.Params) error {
+	if input.OutputOrder != OutputOrderDefault {
+		if input.OutputOrder != OutputOrderBIP69 && input.OutputOrder != OutputOrderRandom {
+			return fmt.Errorf("output order %s is not support", input.OutputOrder)
+		}
+
+		//omni reads the last output as reference
+		if input.isOmni() {
+			return errors.New("omni transaction keeps its own output order")
+		}
+
+		//SIGHASH_SINGLE pairs input with output of the same index
+		single, err := input.hasSigHashSingle()
+		if err != nil {
+			return err
+		}
+
+		if single {
+			return errors.New("SIGHASH_SINGLE input needs the default output order")
+		}
+	}
+
+	if len(input.ChangeCandidates) > 0 {
+		changeAddress, err := input.matchChangeAddress(params)
+		if err != nil {
+			return err
+		}
+		input.ChangeAddress = changeAddress
+	}
+
+	if input.AntiFeeSniping && input.LockTime == 0 {
+		lockTime, err := antiFeeSnipingLockTime(input.BlockHeight)
+		if err != nil {
+			return err
+		}
+		input.LockTime = lockTime
+	}
+
+	//inputs are added in order of utxos
+	utxos := append([]Utxo{}, input.Utxos...)
+	switch input.OutputOrder {
+	case OutputOrderBIP69:
+		//txid in hex is the reversed hash bytes BIP-69 compares
+		sort.SliceStable(utxos, func(i, j int) bool {
+			txidI, txidJ := strings.ToLower(utxos[i].TxID), strings.ToLower(utxos[j].TxID)
+			if txidI != txidJ {
+				return txidI < txidJ
+			}
+
+			return utxos[i].OutputIndex < utxos[j].OutputIndex
+		})
+	case OutputOrderRandom:
+		for i := len(utxos) - 1; i > 0; i-- {
+			j, err := randInt(i + 1)
+			if err != nil {
+				return err
+			}
+			utxos[i], utxos[j] = utxos[j], utxos[i]
+		}
+	}
+	input.Utxos = utxos
+
+	return nil
+}
+
+//matchChangeAddress change candidate of the same script type as all recipients, ChangeAddress otherwise
+func (input *BTCTxInput) matchChangeAddress(params *chaincfg.Params) (string, error) {
+	recipientClass := txscript.NonStandardTy
+	for i, v := range input.To {
+		pkScript, err := getPayToAddrScript(v.To, params)
+		if err != nil {
+			return "", err
+		}
+
+		class := txscript.GetScriptClass(pkScript)
+		if i > 0 && class != recipientClass {
+			return input.ChangeAddress, nil
+		}
+		recipientClass = class
+	}
+
+	for _, candidate := range input.ChangeCandidates {
+		pkScript, err := getPayToAddrScript(candidate, params)
+		if err != nil {
+			return "", fmt.Errorf("change candidate: %v", err)
+		}
+
+		if txscript.GetScriptClass(pkScript) == recipientClass {
+			return candidate, nil
+		}
+	}
+
+	return input.ChangeAddress, nil
+}
+
+//antiFeeSnipingLockTime current height like Bitcoin Core, sometimes up to 99 blocks earlier
+//so that transactions delayed by a slow signer do not stand out
+func antiFeeSnipingLockTime(blockHeight uint32) (uint32, error) {
+	if blockHeight == 0 {
+		return 0, errors.New("anti-fee-sniping needs current block height")
+	}
+
+	lockTime := blockHeight
+	chance, err := randInt(10)
+	if err != nil {
+		return 0, err
+	}
+
+	if chance == 0 {
+		back, err := randInt(100)
+		if err != nil {
+			return 0, err
+		}
+
+		if uint32(back) < lockTime {
+			lockTime -= uint32(back)
+		}
+	}
+
+	return lockTime, nil
+}
+
+//orderOutputs reorder outputs of tx without inputs by output order
+func orderOutputs(tx *wire.MsgTx, order string) error {
+	switch order {
+	case OutputOrderBIP69:
+		txsort.InPlaceSort(tx)
+	case OutputOrderRandom:
+		for i := len(tx.TxOut) - 1; i > 0; i-- {
+			j, err := randInt(i + 1)
+			if err != nil {
+				return err
+			}
+			tx.TxOut[i], tx.TxOut[j] = tx.TxOut[j], tx.TxOut[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//outpointOf outpoint of utxo as "txid:index"
+func outpointOf(utxo *Utxo) string {
+	return fmt.Sprintf("%s:%d", utxo.TxID, utxo.OutputIndex)
+}
+
+//selectedUtxos utxos listed in Selected, all utxos if Selected is empty
+func (input SweepInput) selectedUtxos() ([]Utxo, error) {
+	if len(input.Selected) == 0 {
+		return input.Utxos, nil
+	}
+
+	byOutpoint := make(map[string]Utxo)
+	for _, utxo := range input.Utxos {
+		byOutpoint[outpointOf(&utxo)] = utxo
+	}
+
+	utxos := make([]Utxo, 0, len(input.Selected))
+	seen := make(map[string]bool)
+	for _, outpoint := range input.Selected {
+		utxo, ok := byOutpoint[outpoint]
+		if !ok {
+			return nil, fmt.Errorf("selected utxo %s is not found", outpoint)
+		}
+
+		if seen[outpoint] {
+			return nil, fmt.Errorf("utxo %s is selected twice", outpoint)
+		}
+		seen[outpoint] = true
+
+		utxos = append(utxos, utxo)
+	}
+
+	return utxos, nil
+}
+
+//inputWeights estimated weight of every utxo once signed
+func inputWeights(utxos []Utxo, params *chaincfg.Params) ([]int64, error) {
+	weights := make([]int64, len(utxos))
+	for i := range utxos {
+		prevOut, err := getPrevOut(&utxos[i], params)
+		if err != nil {
+			return nil, err
+		}
+
+		if weights[i], err = estimateInputWeight(&utxos[i], prevOut, params); err != nil {
+			return nil, err
+		}
+	}
+
+	return weights, nil
+}
+
+//sweepBTC spend utxos to a single output paying the fee of feeRate
+func sweepBTC(input SweepInput, signer hdwallet.Signer) (*SweepTransaction, error) {
+	params, err := hdwallet.GetNetParams(input.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.FeeRate <= 0 {
+		return nil, errors.New("fee rate should be positive")
+	}
+
+	if err := checkBTCAddress(input.To, params); err != nil {
+		return nil, err
+	}
+
+	utxos, err := input.selectedUtxos()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(utxos) == 0 {
+		return nil, errors.New("no utxos to sweep")
+	}
+
+	weights, err := inputWeights(utxos, params)
+	if err != nil {
+		return nil, err
+	}
+
+	total := int64(0)
+	for _, utxo := range utxos {
+		total += utxo.Satoshis
+	}
+
+	out, err := getTxOut(input.To, 0, params)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := feeOfWeight(estimateTxWeight(weights, []*wire.TxOut{out}), input.FeeRate)
+
+	amount := total - fee
+	if dust := getDustThreshold(out.PkScript, input.DustRelayFee); amount < dust {
+		return nil, fmt.Errorf("swept amount %d after fee %d is below dust threshold %d", amount, fee, dust)
+	}
+
+	tx, _, err := buildBTCTx(BTCTxInput{
+		CoinType:     input.CoinType,
+		Network:      input.Network,
+		Utxos:        utxos,
+		To:           []WlTo{{To: input.To, Satoshis: amount}},
+		Fee:          fee,
+		LockTime:     input.LockTime,
+		DustRelayFee: input.DustRelayFee,
+	}, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SweepTransaction{
+		TransactionBTC: TransactionBTC{
+			TxID:  tx.TxHash().String(),
+			HexTx: txToHex(tx),
+		},
+		Fee:      fee,
+		Satoshis: amount,
+	}, nil
+}
+
+//SweepBTC send max: spend all or selected utxos to one address, json of SweepInput in and SweepTransaction out
+func SweepBTC(sweep string) (string, error) {
+	return SweepBTCWithSigner(sweep, nil)
+}
+
+//SweepBTCWithSigner like SweepBTC, utxos without private key are signed by signer with Utxo.KeyID
+func SweepBTCWithSigner(sweep string, signer hdwallet.Signer) (string, error) {
+	var input SweepInput
+	if err := json.Unmarshal([]byte(sweep), &input); err != nil {
+		return "", err
+	}
+
+	result, err := sweepBTC(input, signer)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+//planConsolidation merge the smallest economical utxos under weight limit, then compare the fee paid now
+//with the fees of spending them one by one at long term fee rate
+func planConsolidation(input ConsolidationInput) (*ConsolidationPlan, error) {
+	params, err := hdwallet.GetNetParams(input.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.FeeRate <= 0 || input.LongTermFeeRate <= 0 {
+		return nil, errors.New("fee rates should be positive")
+	}
+
+	if input.MaxWeight <= 0 {
+		input.MaxWeight = MaxStandardTxWeight
+	}
+
+	if err := checkBTCAddress(input.To, params); err != nil {
+		return nil, err
+	}
+
+	out, err := getTxOut(input.To, 0, params)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedWeight, err := estimateInputWeight(&Utxo{TxID: "merged"}, out, params)
+	if err != nil {
+		return nil, fmt.Errorf("consolidation address: %v", err)
+	}
+
+	utxos := append([]Utxo{}, input.Utxos...)
+	weights, err := inputWeights(utxos, params)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(utxos))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return utxos[order[a]].Satoshis < utxos[order[b]].Satoshis
+	})
+
+	plan := &ConsolidationPlan{
+		Selected:     make([]string, 0),
+		Uneconomical: make([]string, 0),
+	}
+
+	selected := make([]int64, 0, len(utxos))
+	outputs := []*wire.TxOut{out}
+	for _, i := range order {
+		//input costs more than its value at current fee rate
+		if utxos[i].Satoshis*4 <= weights[i]*input.FeeRate {
+			plan.Uneconomical = append(plan.Uneconomical, outpointOf(&utxos[i]))
+			continue
+		}
+
+		if estimateTxWeight(append(selected, weights[i]), outputs) > input.MaxWeight {
+			break
+		}
+
+		selected = append(selected, weights[i])
+		plan.Selected = append(plan.Selected, outpointOf(&utxos[i]))
+		plan.Satoshis += utxos[i].Satoshis
+	}
+
+	if len(selected) < 2 {
+		return plan, nil
+	}
+
+	plan.Fee = feeOfWeight(estimateTxWeight(selected, outputs), input.FeeRate)
+
+	separateWeight := int64(0)
+	for _, w := range selected {
+		separateWeight += w
+	}
+
+	//spending the inputs later versus spending the merged output later
+	later := separateWeight * input.LongTermFeeRate / 4
+	mergedLater := mergedWeight * input.LongTermFeeRate / 4
+	plan.Savings = later - mergedLater - plan.Fee
+	plan.Worthwhile = plan.Savings > 0
+
+	return plan, nil
+}
+
+//PlanConsolidation tell whether merging utxos is cheap now, json of ConsolidationInput in and ConsolidationPlan out
+func PlanConsolidation(consolidation string) (string, error) {
+	var input ConsolidationInput
+	if err := json.Unmarshal([]byte(consolidation), &input); err != nil {
+		return "", err
+	}
+
+	plan, err := planConsolidation(input)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(plan)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,198 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+//bech32mConst checksum constant of BIP-350 bech32m
+const bech32mConst = 0x2bc830a3
+
+//bech32Charset data characters of bech32 and bech32m
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	gen := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	values := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	return values
+}
+
+//decodeTaprootAddress witness program of bech32m P2TR address of params
+func decodeTaprootAddress(address string, params *chaincfg.Params) ([]byte, error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return nil, errors.New("mixed case address")
+	}
+	address = strings.ToLower(address)
+
+	pos := strings.LastIndexByte(address, '1')
+	if pos < 1 || pos+7 > len(address) || len(address) > 90 {
+		return nil, errors.New("invalid bech32m address")
+	}
+
+	hrp := address[:pos]
+	if hrp != params.Bech32HRPSegwit {
+		return nil, fmt.Errorf("address is not for network %s", params.Name)
+	}
+
+	data := make([]byte, 0, len(address)-pos-1)
+	for _, c := range address[pos+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return nil, fmt.Errorf("invalid bech32m character %q", c)
+		}
+		data = append(data, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HrpExpand(hrp), data...)) != bech32mConst {
+		return nil, errors.New("invalid bech32m checksum")
+	}
+
+	data = data[:len(data)-6]
+	if len(data) == 0 || data[0] != 1 {
+		return nil, errors.New("address is not witness version 1")
+	}
+
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(program) != 32 {
+		return nil, fmt.Errorf("taproot program should be 32 bytes, got %d", len(program))
+	}
+
+	return program, nil
+}
+
+//encodeTaprootAddress bech32m P2TR address of 32 bytes output key
+func encodeTaprootAddress(program []byte, params *chaincfg.Params) (string, error) {
+	conv, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	hrp := params.Bech32HRPSegwit
+	data := append([]byte{1}, conv...)
+	values := append(bech32HrpExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ bech32mConst
+	for i := 0; i < 6; i++ {
+		data = append(data, byte(polymod>>uint(5*(5-i)))&31)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range data {
+		sb.WriteByte(bech32Charset[v])
+	}
+
+	return sb.String(), nil
+}
+
+//taprootAddress P2TR address, which btcutil does not know
+type taprootAddress struct {
+	program []byte
+	params  *chaincfg.Params
+}
+
+//String address of bech32m
+func (a *taprootAddress) String() string {
+	return a.EncodeAddress()
+}
+
+//EncodeAddress address of bech32m
+func (a *taprootAddress) EncodeAddress() string {
+	address, _ := encodeTaprootAddress(a.program, a.params)
+	return address
+}
+
+//ScriptAddress output key
+func (a *taprootAddress) ScriptAddress() []byte {
+	return a.program
+}
+
+//IsForNet address is for net
+func (a *taprootAddress) IsForNet(params *chaincfg.Params) bool {
+	return a.params.Bech32HRPSegwit == params.Bech32HRPSegwit
+}
+
+//pkScript OP_1 <output key>
+func (a *taprootAddress) pkScript() ([]byte, error) {
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_1).AddData(a.program).Script()
+}
+
+//taprootSigHash BIP-341 signature hash of key path spending input idx, hashType is
+//SIGHASH_DEFAULT or SIGHASH_ALL
+func taprootSigHash(tx *wire.MsgTx, prevOuts []*wire.TxOut, idx int, hashType txscript.SigHashType) ([]byte, error) {
+	if hashType != 0 && hashType != txscript.SigHashAll {
+		return nil, fmt.Errorf("sighash type %d is not supported", hashType)
+	}
+
+	if len(prevOuts) != len(tx.TxIn) || idx < 0 || idx >= len(tx.TxIn) {
+		return nil, errors.New("previous outputs do not match inputs")
+	}
+
+	var prevouts, amounts, scripts, sequences, outputs bytes.Buffer
+	for i, txIn := range tx.TxIn {
+		prevouts.Write(txIn.PreviousOutPoint.Hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, txIn.PreviousOutPoint.Index)
+		binary.Write(&amounts, binary.LittleEndian, prevOuts[i].Value)
+		wire.WriteVarBytes(&scripts, 0, prevOuts[i].PkScript)
+		binary.Write(&sequences, binary.LittleEndian, txIn.Sequence)
+	}
+
+	for _, txOut := range tx.TxOut {
+		binary.Write(&outputs, binary.LittleEndian, txOut.Value)
+		wire.WriteVarBytes(&outputs, 0, txOut.PkScript)
+	}
+
+	var msg bytes.Buffer
+	//epoch and hash type
+	msg.Write([]byte{0, byte(hashType)})
+	binary.Write(&msg, binary.LittleEndian, tx.Version)
+	binary.Write(&msg, binary.LittleEndian, tx.LockTime)
+	for _, b := range []*bytes.Buffer{&prevouts, &amounts, &scripts, &sequences, &outputs} {
+		h := sha256.Sum256(b.Bytes())
+		msg.Write(h[:])
+	}
+	//key path without annex
+	msg.WriteByte(0)
+	binary.Write(&msg, binary.LittleEndian, uint32(idx))
+
+	return hdwallet.TaggedHash("TapSighash", msg.Bytes()), nil
+}
@@ -0,0 +1,965 @@
+package blockchain
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestTransferBTC(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+	program, _ := txscript.PayToAddrScript(native)
+
+	legacy := hdwallet.ToBTC(pkData, false)
+	nested := hdwallet.ToBTC(pkData, true)
+
+	cases := []struct {
+		address  string
+		pkScript string
+		ok       bool
+	}{
+		{legacy, pkScriptHex(legacy), true},
+		{nested, pkScriptHex(nested), true},
+		{nested, hex.EncodeToString(program), true},
+		{native.EncodeAddress(), hex.EncodeToString(program), true},
+		{legacy, hex.EncodeToString(program), false},
+	}
+
+	for _, c := range cases {
+		input := BTCTxInput{
+			Utxos: []Utxo{{
+				Address:     c.address,
+				TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+				OutputIndex: 1,
+				PkScript:    c.pkScript,
+				Satoshis:    100000,
+				Private:     private,
+			}},
+			To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 50000}},
+			ChangeAddress: c.address,
+			Fee:           1000,
+		}
+		inputBytes, _ := json.Marshal(input)
+
+		tx, err := TransferBTC(string(inputBytes))
+		if (err == nil) != c.ok {
+			t.Errorf("TransferBTC %s: %v\n", c.address, err)
+			continue
+		}
+
+		if err != nil {
+			if inErr, ok := err.(*InputError); !ok || inErr.Index != 0 || inErr.OutputIndex != 1 {
+				t.Errorf("TransferBTC %s should fail with InputError: %v\n", c.address, err)
+			}
+			continue
+		}
+
+		fmt.Printf("%s tx: %v %v\n", c.address, tx.TxID, tx.HexTx)
+	}
+
+	//outputs and fee over utxos, or negative fee, never give a transaction
+	for _, amount := range []struct{ satoshis, fee int64 }{{99500, 1000}, {100001, 0}, {50000, -1000}} {
+		input := BTCTxInput{
+			Utxos: []Utxo{{
+				Address:     legacy,
+				TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+				OutputIndex: 1,
+				Satoshis:    100000,
+				Private:     private,
+			}},
+			To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: amount.satoshis}},
+			ChangeAddress: legacy,
+			Fee:           amount.fee,
+		}
+		inputBytes, _ := json.Marshal(input)
+
+		if tx, err := TransferBTC(string(inputBytes)); err == nil {
+			t.Errorf("TransferBTC of %d satoshis with fee %d should fail: %v\n", amount.satoshis, amount.fee, tx.HexTx)
+		}
+	}
+}
+
+func TestTransferBTCWithSigner(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+
+	keySigner := hdwallet.NewKeySigner()
+	keySigner.AddHexKey("m/84'/0'/0'/0/0", private)
+
+	for _, isSegwit := range []bool{false, true} {
+		from := hdwallet.ToBTC(pkData, isSegwit)
+		input := BTCTxInput{
+			Utxos: []Utxo{{
+				Address:     from,
+				TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+				OutputIndex: 1,
+				PkScript:    pkScriptHex(from),
+				Satoshis:    100000,
+				Private:     private,
+			}},
+			To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 50000}},
+			ChangeAddress: from,
+			Fee:           1000,
+		}
+
+		inputBytes, _ := json.Marshal(input)
+		expected, err := TransferBTC(string(inputBytes))
+		if err != nil {
+			t.Fatalf("TransferBTC: %v\n", err)
+		}
+
+		input.Utxos[0].Private = ""
+		input.Utxos[0].KeyID = "m/84'/0'/0'/0/0"
+		inputBytes, _ = json.Marshal(input)
+
+		signer := hdwallet.NewMockSigner(keySigner)
+		tx, err := TransferBTCWithSigner(string(inputBytes), signer)
+		if err != nil {
+			t.Fatalf("TransferBTCWithSigner: %v\n", err)
+		}
+
+		//RFC6979 signatures are deterministic
+		if tx.HexTx != expected.HexTx || signer.SignCount() != 1 {
+			t.Errorf("signer transaction %v, expected %v\n", tx.HexTx, expected.HexTx)
+		}
+
+		if _, err := TransferBTC(string(inputBytes)); err == nil {
+			t.Errorf("utxo without private key should fail without signer\n")
+		}
+	}
+}
+
+func TestTransferBTCData(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	from := hdwallet.ToBTC(key.PubKey().SerializeCompressed(), false)
+
+	cases := []struct {
+		data    DataOutput
+		omni    bool
+		payload string
+		ok      bool
+	}{
+		{DataOutput{Text: "invoice 2026-10-19"}, false, hex.EncodeToString([]byte("invoice 2026-10-19")), true},
+		{DataOutput{Hex: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}, false,
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", true},
+		{DataOutput{Text: strings.Repeat("a", 80)}, false, hex.EncodeToString([]byte(strings.Repeat("a", 80))), true},
+		{DataOutput{Text: strings.Repeat("a", 81)}, false, "", false},
+		{DataOutput{Hex: "zz"}, false, "", false},
+		{DataOutput{Hex: "00", Text: "a"}, false, "", false},
+		{DataOutput{Text: "memo"}, true, "", false},
+	}
+
+	for _, c := range cases {
+		input := BTCTxInput{
+			Utxos: []Utxo{{
+				Address:     from,
+				TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+				OutputIndex: 0,
+				PkScript:    pkScriptHex(from),
+				Satoshis:    100000,
+				Private:     private,
+			}},
+			To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 50000}},
+			ChangeAddress: from,
+			Fee:           1000,
+			Data:          &c.data,
+		}
+		if c.omni {
+			input.OmniCurrencyID = 31
+			input.OmniAmount = 100000000
+		}
+
+		tx, _, err := buildBTCTx(input, nil)
+		if (err == nil) != c.ok {
+			t.Errorf("data output %v: %v\n", c.data, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		res, _ := DecodeBTCTransaction(txToHex(tx), "", "mainnet")
+
+		var decoded DecodedBTCTx
+		json.Unmarshal([]byte(res), &decoded)
+
+		last := decoded.Outputs[len(decoded.Outputs)-1]
+		if last.Type != "nulldata" || last.Data != c.payload || last.Satoshis != 0 {
+			t.Errorf("data output mismatch: %v\n", res)
+		}
+	}
+}
+
+func TestDecodeBTCTransaction(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	from := hdwallet.ToBTC(key.PubKey().SerializeCompressed(), false)
+
+	input := BTCTxInput{
+		Utxos: []Utxo{{
+			Address:     from,
+			TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 0,
+			PkScript:    pkScriptHex(from),
+			Satoshis:    100000,
+			Private:     private,
+		}},
+		To:             []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 546}},
+		ChangeAddress:  from,
+		Fee:            1000,
+		Dust:           546,
+		OmniCurrencyID: 31,
+		OmniAmount:     100000000,
+	}
+
+	tx, _, err := buildBTCTx(input, nil)
+	if err != nil {
+		t.Fatalf("buildBTCTx: %v\n", err)
+	}
+
+	res, err := DecodeBTCTransaction(txToHex(tx), "[100000]", "mainnet")
+	if err != nil {
+		t.Fatalf("DecodeBTCTransaction: %v\n", err)
+	}
+	fmt.Printf("decoded: %v\n", res)
+
+	var decoded DecodedBTCTx
+	json.Unmarshal([]byte(res), &decoded)
+
+	fee := int64(100000)
+	for _, out := range tx.TxOut {
+		fee -= out.Value
+	}
+
+	if decoded.TxID != tx.TxHash().String() || !decoded.HasFee || decoded.Fee != fee || !decoded.RBF {
+		t.Errorf("decoded transaction mismatch: %v\n", res)
+	}
+
+	omniFound := false
+	for _, out := range decoded.Outputs {
+		if out.Omni != nil {
+			omniFound = out.Omni.PropertyID == 31 && out.Omni.Amount == 100000000
+		}
+	}
+
+	if !omniFound {
+		t.Errorf("omni payload not decoded: %v\n", res)
+	}
+}
+
+func TestSigHashTypes(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+	legacy := hdwallet.ToBTC(pkData, false)
+
+	input := BTCTxInput{
+		Utxos: []Utxo{{
+			Address:     native.EncodeAddress(),
+			TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 0,
+			PkScript:    pkScriptHex(native.EncodeAddress()),
+			Satoshis:    100000,
+			Private:     private,
+			SigHash:     "ALL|ANYONECANPAY",
+		}, {
+			Address:     legacy,
+			TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 1,
+			PkScript:    pkScriptHex(legacy),
+			Satoshis:    100000,
+			Private:     private,
+			SigHash:     "SIGHASH_SINGLE",
+		}},
+		To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 50000}},
+		ChangeAddress: legacy,
+		Fee:           1000,
+	}
+
+	tx, _, err := buildBTCTx(input, nil)
+	if err != nil {
+		t.Fatalf("buildBTCTx: %v\n", err)
+	}
+
+	prevOuts := make([]*wire.TxOut, 0)
+	for i := range input.Utxos {
+		prevOut, _ := getPrevOut(&input.Utxos[i], &chaincfg.MainNetParams)
+		prevOuts = append(prevOuts, prevOut)
+	}
+
+	verify := func(tx *wire.MsgTx, i int) error {
+		vm, err := txscript.NewEngine(prevOuts[i].PkScript, tx, i, txscript.StandardVerifyFlags,
+			nil, txscript.NewTxSigHashes(tx), prevOuts[i].Value)
+		if err != nil {
+			return err
+		}
+
+		return vm.Execute()
+	}
+
+	//ANYONECANPAY input stays valid when others add inputs
+	withInput := tx.Copy()
+	withInput.AddTxIn(wire.NewTxIn(&tx.TxIn[0].PreviousOutPoint, nil, nil))
+	withInput.TxIn[2].PreviousOutPoint.Index = 2
+	if verify(withInput, 0) != nil || verify(withInput, 1) == nil {
+		t.Errorf("only ANYONECANPAY input should survive a new input\n")
+	}
+
+	//SINGLE input stays valid when others add outputs
+	withOutput := tx.Copy()
+	withOutput.AddTxOut(wire.NewTxOut(1000, tx.TxOut[0].PkScript))
+	if verify(withOutput, 1) != nil || verify(withOutput, 0) == nil {
+		t.Errorf("only SINGLE input should survive a new output\n")
+	}
+
+	single := input
+	single.Utxos = append([]Utxo{}, input.Utxos...)
+	single.Utxos = append(single.Utxos, input.Utxos[1], input.Utxos[1])
+	single.Utxos[3].OutputIndex = 3
+	if _, _, err := buildBTCTx(single, nil); err == nil {
+		t.Errorf("SIGHASH_SINGLE without output of the same index should fail\n")
+	}
+
+	//input i signs recipient To[i], change goes last
+	paired := input
+	paired.Utxos = append([]Utxo{}, input.Utxos...)
+	paired.Utxos[0].SigHash = "SINGLE|ANYONECANPAY"
+	paired.To = []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 50000}, {To: native.EncodeAddress(), Satoshis: 30000}}
+
+	tx, _, err = buildBTCTx(paired, nil)
+	if err != nil {
+		t.Fatalf("buildBTCTx SINGLE pairs: %v\n", err)
+	}
+
+	if len(tx.TxOut) != 3 || tx.TxOut[2].Value != 200000-80000-1000 {
+		t.Fatalf("change of SIGHASH_SINGLE should be last: %v\n", tx.TxOut)
+	}
+
+	for i, to := range paired.To {
+		if hex.EncodeToString(tx.TxOut[i].PkScript) != pkScriptHex(to.To) || tx.TxOut[i].Value != to.Satoshis {
+			t.Errorf("output %d should pay %s\n", i, to.To)
+		}
+
+		//changing output i breaks only input i
+		changed := tx.Copy()
+		changed.TxOut[i].Value--
+		for j := range paired.To {
+			if err := verify(changed, j); (err == nil) != (i != j) {
+				t.Errorf("input %d after output %d changed: %v\n", j, i, err)
+			}
+		}
+	}
+
+	invalid := input
+	invalid.Utxos = append([]Utxo{}, input.Utxos...)
+	invalid.Utxos[0].SigHash = "ALL|SINGLE"
+	if _, _, err := buildBTCTx(invalid, nil); err == nil {
+		t.Errorf("invalid sighash type should fail\n")
+	}
+}
+
+func TestDustThreshold(t *testing.T) {
+	cases := []struct {
+		address      string
+		dustRelayFee int64
+		dust         int64
+	}{
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", 0, 546},
+		{"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", 0, 540},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0, 294},
+		{"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", 0, 330},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", 0, 330},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", 1000, 182},
+	}
+
+	for _, c := range cases {
+		dust, err := GetDustThreshold(c.address, "mainnet", c.dustRelayFee)
+		if err != nil || dust != c.dust {
+			t.Errorf("GetDustThreshold %s at %d: %d %v\n", c.address, c.dustRelayFee, dust, err)
+		}
+	}
+
+	//witness v1 program of P2TR
+	taproot, _ := hex.DecodeString("5120" + strings.Repeat("ab", 32))
+	if dust := getDustThreshold(taproot, 0); dust != 330 {
+		t.Errorf("P2TR dust threshold %d\n", dust)
+	}
+
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+	legacy := hdwallet.ToBTC(pkData, false)
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+
+	changes := []struct {
+		changeAddress string
+		dropped       int64
+	}{
+		{legacy, 300},
+		{native.EncodeAddress(), 0},
+	}
+
+	for _, c := range changes {
+		input := BTCTxInput{
+			Utxos: []Utxo{{
+				Address:     legacy,
+				TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+				OutputIndex: 0,
+				PkScript:    pkScriptHex(legacy),
+				Satoshis:    100000,
+				Private:     private,
+			}},
+			To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 98700}},
+			ChangeAddress: c.changeAddress,
+			Fee:           1000,
+		}
+		inputBytes, _ := json.Marshal(input)
+
+		tx, err := TransferBTC(string(inputBytes))
+		if err != nil || tx.DroppedChange != c.dropped {
+			t.Errorf("change of 300 to %s: %v %v\n", c.changeAddress, tx, err)
+		}
+
+		input.To[0].Satoshis = 545
+		inputBytes, _ = json.Marshal(input)
+		if _, err := TransferBTC(string(inputBytes)); err == nil {
+			t.Errorf("recipient output below dust should fail\n")
+		}
+	}
+
+	//pay to P2TR of BIP-350 vector
+	input := BTCTxInput{
+		Utxos: []Utxo{{
+			Address:     legacy,
+			TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 0,
+			PkScript:    pkScriptHex(legacy),
+			Satoshis:    100000,
+			Private:     private,
+		}},
+		To:            []WlTo{{To: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", Satoshis: 330}},
+		ChangeAddress: legacy,
+		Fee:           1000,
+	}
+
+	tx, _, err := buildBTCTx(input, nil)
+	if err != nil {
+		t.Fatalf("pay to P2TR: %v\n", err)
+	}
+
+	program := "5120" + "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	if out := tx.TxOut[len(tx.TxOut)-1]; hex.EncodeToString(out.PkScript) != program || out.Value != 330 {
+		t.Errorf("P2TR output mismatch: %x %d\n", out.PkScript, out.Value)
+	}
+
+	input.To[0].Satoshis = 329
+	if _, _, err := buildBTCTx(input, nil); err == nil {
+		t.Errorf("P2TR output below dust should fail\n")
+	}
+}
+
+func TestPrivacyOptions(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+	legacy := hdwallet.ToBTC(pkData, false)
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+
+	newInput := func() BTCTxInput {
+		utxo := Utxo{
+			Address:     legacy,
+			TxID:        "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 1,
+			PkScript:    pkScriptHex(legacy),
+			Satoshis:    100000,
+			Private:     private,
+		}
+		other := utxo
+		other.TxID = "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa"
+
+		return BTCTxInput{
+			Utxos:            []Utxo{utxo, other},
+			To:               []WlTo{{To: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Satoshis: 150000}},
+			ChangeAddress:    legacy,
+			ChangeCandidates: []string{legacy, native.EncodeAddress()},
+			Fee:              1000,
+		}
+	}
+
+	input := newInput()
+	input.OutputOrder = OutputOrderBIP69
+	input.AntiFeeSniping = true
+	input.BlockHeight = 800000
+
+	tx, _, err := buildBTCTx(input, nil)
+	if err != nil {
+		t.Fatalf("buildBTCTx: %v\n", err)
+	}
+
+	if tx.TxIn[0].PreviousOutPoint.Hash.String() != input.Utxos[1].TxID {
+		t.Errorf("inputs are not in BIP-69 order\n")
+	}
+
+	//change of 49000 before payment of 150000, change matches the P2WPKH recipient
+	if tx.TxOut[0].Value != 49000 || txscript.GetScriptClass(tx.TxOut[0].PkScript) != txscript.WitnessV0PubKeyHashTy {
+		t.Errorf("change output mismatch: %v\n", tx.TxOut[0])
+	}
+
+	if tx.LockTime > 800000 || tx.LockTime < 800000-99 {
+		t.Errorf("anti-fee-sniping locktime %d\n", tx.LockTime)
+	}
+
+	input = newInput()
+	input.OutputOrder = OutputOrderRandom
+	if _, _, err := buildBTCTx(input, nil); err != nil {
+		t.Errorf("random order: %v\n", err)
+	}
+
+	input = newInput()
+	input.AntiFeeSniping = true
+	if _, _, err := buildBTCTx(input, nil); err == nil {
+		t.Errorf("anti-fee-sniping without block height should fail\n")
+	}
+
+	input = newInput()
+	input.OutputOrder = OutputOrderBIP69
+	input.OmniCurrencyID = 31
+	input.OmniAmount = 100000000
+	if _, _, err := buildBTCTx(input, nil); err == nil {
+		t.Errorf("omni transaction should keep its output order\n")
+	}
+
+	input = newInput()
+	input.ChangeCandidates = []string{"bc1qinvalid"}
+	if _, _, err := buildBTCTx(input, nil); err == nil {
+		t.Errorf("invalid change candidate should fail\n")
+	}
+}
+
+func TestBTCMessage(t *testing.T) {
+	//BIP-322 test vectors
+	if hex.EncodeToString(bip322MessageHash("Hello World")) != "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a" {
+		t.Errorf("BIP-322 message hash mismatch\n")
+	}
+
+	vector := "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	ok, err := VerifyBTCMessage("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", "Hello World", vector, "mainnet")
+	if err != nil || !ok {
+		t.Errorf("BIP-322 vector should verify: %v\n", err)
+	}
+
+	ok, _ = VerifyBTCMessage("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", "Hello World!", vector, "mainnet")
+	if ok {
+		t.Errorf("BIP-322 vector should not verify another message\n")
+	}
+
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+	legacy := hdwallet.ToBTC(pkData, false)
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+	program, _ := txscript.PayToAddrScript(native)
+	nested, _ := btcutil.NewAddressScriptHash(program, &chaincfg.MainNetParams)
+
+	for _, address := range []string{legacy, nested.EncodeAddress(), native.EncodeAddress()} {
+		sig, err := SignBTCMessage(private, address, "Hello World", "mainnet")
+		if err != nil {
+			t.Errorf("SignBTCMessage %s: %v\n", address, err)
+			continue
+		}
+		fmt.Printf("%s: %s\n", address, sig)
+
+		if ok, err := VerifyBTCMessage(address, "Hello World", sig, "mainnet"); err != nil || !ok {
+			t.Errorf("VerifyBTCMessage %s: %v\n", address, err)
+		}
+
+		if ok, _ := VerifyBTCMessage(address, "Hello", sig, "mainnet"); ok {
+			t.Errorf("signature of %s should not verify another message\n", address)
+		}
+	}
+
+	if _, err := SignBTCMessage(private, "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", "Hello World", "mainnet"); err == nil {
+		t.Errorf("signing for address of another key should fail\n")
+	}
+
+	//BIP-322 taproot vector, SIGHASH_ALL
+	taproot := "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"
+	taprootSig := "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="
+	if ok, err := VerifyBTCMessage(taproot, "Hello World", taprootSig, "mainnet"); err != nil || !ok {
+		t.Errorf("BIP-322 taproot vector should verify: %v\n", err)
+	}
+
+	if ok, _ := VerifyBTCMessage(taproot, "Hello World!", taprootSig, "mainnet"); ok {
+		t.Errorf("BIP-322 taproot vector should not verify another message\n")
+	}
+
+	taprootKey, _, _ := hdwallet.DecodeWIF("L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k", &chaincfg.MainNetParams)
+	taprootPrivate := hex.EncodeToString(taprootKey.Serialize())
+	for _, message := range []string{"", "Hello World"} {
+		sig, err := SignBTCMessage(taprootPrivate, taproot, message, "mainnet")
+		if err != nil {
+			t.Errorf("SignBTCMessage taproot: %v\n", err)
+			continue
+		}
+		fmt.Printf("%s: %s\n", taproot, sig)
+
+		if ok, err := VerifyBTCMessage(taproot, message, sig, "mainnet"); err != nil || !ok {
+			t.Errorf("VerifyBTCMessage taproot %q: %v\n", message, err)
+		}
+
+		if ok, _ := VerifyBTCMessage(taproot, message+"!", sig, "mainnet"); ok {
+			t.Errorf("taproot signature should not verify another message\n")
+		}
+	}
+
+	//key with odd y is negated before tweaking
+	oddPrivate := "0000000000000000000000000000000000000000000000000000000000000006"
+	oddKey, _ := hdwallet.HexToECDSAPrivateKey(oddPrivate)
+	if oddKey.PubKey().SerializeCompressed()[0] != 0x03 {
+		t.Fatalf("public key of %s should have odd y\n", oddPrivate)
+	}
+
+	outputKey, _ := hdwallet.TaprootOutputKey(oddKey.PubKey().SerializeCompressed())
+	ownTaproot, _ := encodeTaprootAddress(outputKey, &chaincfg.MainNetParams)
+	sig, err := SignBTCMessage(oddPrivate, ownTaproot, "Hello World", "mainnet")
+	if err != nil {
+		t.Errorf("SignBTCMessage %s: %v\n", ownTaproot, err)
+	} else if ok, err := VerifyBTCMessage(ownTaproot, "Hello World", sig, "mainnet"); err != nil || !ok {
+		t.Errorf("VerifyBTCMessage %s: %v\n", ownTaproot, err)
+	}
+
+	if _, err := SignBTCMessage(private, taproot, "Hello World", "mainnet"); err == nil {
+		t.Errorf("signing for taproot address of another key should fail\n")
+	}
+
+	//witness count larger than the signature is rejected before allocating
+	if _, err := VerifyBTCMessage(taproot, "Hello World", base64.StdEncoding.EncodeToString([]byte{0xfe, 0xff, 0xff, 0xff, 0x01}), "mainnet"); err == nil {
+		t.Errorf("witness count beyond signature length should fail\n")
+	}
+}
+
+func TestBatchPayout(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+	program, _ := txscript.PayToAddrScript(native)
+
+	utxos := make([]Utxo, 0)
+	for i := 0; i < 3; i++ {
+		utxos = append(utxos, Utxo{
+			Address:     native.EncodeAddress(),
+			TxID:        "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: i,
+			PkScript:    hex.EncodeToString(program),
+			Satoshis:    100000,
+			Private:     private,
+		})
+	}
+
+	input := BatchPayoutInput{
+		Utxos: utxos,
+		To: []BatchTo{
+			{To: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Satoshis: 30000},
+			{To: "1KKKK6N21XKo48zWKuQKXdvSsCf95ibHFa", Satoshis: 40000, SubtractFee: true},
+			{To: "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", Satoshis: 20000},
+		},
+		ChangeAddress: native.EncodeAddress(),
+		FeeRate:       10,
+	}
+
+	b, _ := json.Marshal(input)
+	res, err := BatchPayoutBTC(string(b))
+	if err != nil {
+		t.Fatalf("BatchPayoutBTC: %v\n", err)
+	}
+	fmt.Printf("batch: %s\n", res)
+
+	var batch BatchPayout
+	json.Unmarshal([]byte(res), &batch)
+	if len(batch.Transactions) != 1 || len(batch.Transactions[0].To) != 2 {
+		t.Fatalf("duplicate payouts should be merged into one transaction: %s\n", res)
+	}
+
+	tx := batch.Transactions[0]
+	if tx.To[0].Satoshis != 50000 || tx.To[1].Satoshis != 40000-tx.Fee {
+		t.Errorf("fee should be subtracted from the second payout: %v\n", tx.To)
+	}
+
+	decoded, err := DecodeBTCTransaction(tx.HexTx, "[100000]", "mainnet")
+	if err != nil {
+		t.Fatalf("DecodeBTCTransaction: %v\n", err)
+	}
+
+	var info DecodedBTCTx
+	json.Unmarshal([]byte(decoded), &info)
+	if tx.Fee < int64(info.VSize)*input.FeeRate || info.Fee != tx.Fee {
+		t.Errorf("fee %d does not pay %d vbytes at rate %d\n", tx.Fee, info.VSize, input.FeeRate)
+	}
+
+	//a single payout per transaction under low weight limit
+	input.MaxWeight = 600
+	b, _ = json.Marshal(input)
+	if res, err = BatchPayoutBTC(string(b)); err != nil {
+		t.Fatalf("BatchPayoutBTC with weight limit: %v\n", err)
+	}
+
+	json.Unmarshal([]byte(res), &batch)
+	if len(batch.Transactions) != 2 {
+		t.Errorf("batch should be split in 2 transactions: %s\n", res)
+	}
+
+	input.MaxWeight = 0
+	input.To[1].SubtractFee = false
+	b, _ = json.Marshal(input)
+	if _, err = BatchPayoutBTC(string(b)); err != nil {
+		t.Errorf("BatchPayoutBTC without subtraction: %v\n", err)
+	}
+
+	input.To[2].SubtractFee = true
+	b, _ = json.Marshal(input)
+	if _, err = BatchPayoutBTC(string(b)); err == nil {
+		t.Errorf("payouts to the same address disagreeing on fee should fail\n")
+	}
+}
+
+func TestSweepAndConsolidation(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	pkData := key.PubKey().SerializeCompressed()
+	native, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pkData), &chaincfg.MainNetParams)
+	program, _ := txscript.PayToAddrScript(native)
+
+	utxos := make([]Utxo, 0)
+	for i, satoshis := range []int64{100000, 1000, 50000} {
+		utxos = append(utxos, Utxo{
+			Address:     native.EncodeAddress(),
+			TxID:        "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: i,
+			PkScript:    hex.EncodeToString(program),
+			Satoshis:    satoshis,
+			Private:     private,
+		})
+	}
+
+	sweep := SweepInput{
+		Utxos:    utxos,
+		Selected: []string{outpointOf(&utxos[0]), outpointOf(&utxos[2])},
+		To:       "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		FeeRate:  5,
+	}
+
+	b, _ := json.Marshal(sweep)
+	res, err := SweepBTC(string(b))
+	if err != nil {
+		t.Fatalf("SweepBTC: %v\n", err)
+	}
+	fmt.Printf("sweep: %s\n", res)
+
+	var swept SweepTransaction
+	json.Unmarshal([]byte(res), &swept)
+	if swept.Satoshis+swept.Fee != 150000 {
+		t.Errorf("sweep should spend the selected 150000 satoshis: %s\n", res)
+	}
+
+	decoded, _ := DecodeBTCTransaction(swept.HexTx, "[100000,50000]", "mainnet")
+	var info DecodedBTCTx
+	json.Unmarshal([]byte(decoded), &info)
+	if len(info.Inputs) != 2 || len(info.Outputs) != 1 || swept.Fee < int64(info.VSize)*sweep.FeeRate {
+		t.Errorf("sweep fee %d does not pay %d vbytes: %s\n", swept.Fee, info.VSize, decoded)
+	}
+
+	sweep.Selected = []string{outpointOf(&utxos[1])}
+	sweep.FeeRate = 50
+	b, _ = json.Marshal(sweep)
+	if _, err := SweepBTC(string(b)); err == nil {
+		t.Errorf("sweep below dust threshold should fail\n")
+	}
+
+	consolidation := ConsolidationInput{
+		Utxos:           utxos,
+		To:              native.EncodeAddress(),
+		FeeRate:         1,
+		LongTermFeeRate: 20,
+	}
+
+	b, _ = json.Marshal(consolidation)
+	res, err = PlanConsolidation(string(b))
+	if err != nil {
+		t.Fatalf("PlanConsolidation: %v\n", err)
+	}
+
+	var plan ConsolidationPlan
+	json.Unmarshal([]byte(res), &plan)
+	if !plan.Worthwhile || len(plan.Selected) != 3 || plan.Selected[0] != outpointOf(&utxos[1]) {
+		t.Errorf("consolidation at low fee rate should be worthwhile: %s\n", res)
+	}
+
+	consolidation.FeeRate = 50
+	consolidation.LongTermFeeRate = 5
+	b, _ = json.Marshal(consolidation)
+	res, _ = PlanConsolidation(string(b))
+
+	plan = ConsolidationPlan{}
+	json.Unmarshal([]byte(res), &plan)
+	if plan.Worthwhile || len(plan.Uneconomical) != 1 || len(plan.Selected) != 2 {
+		t.Errorf("consolidation at high fee rate should not be worthwhile: %s\n", res)
+	}
+}
+
+func TestSweepPaperWallet(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	wif := HexToWIF(private)
+
+	res, err := PaperWalletAddresses(wif, "mainnet")
+	if err != nil {
+		t.Fatalf("PaperWalletAddresses: %v\n", err)
+	}
+	fmt.Printf("paper wallet: %s\n", res)
+
+	var addresses []PaperWalletAddress
+	json.Unmarshal([]byte(res), &addresses)
+	if len(addresses) != 4 || addresses[0].Compressed || addresses[3].Type != "p2wpkh" {
+		t.Fatalf("candidate addresses mismatch: %s\n", res)
+	}
+
+	if _, err := PaperWalletAddresses(HexToWIFWithNetwork(private, "testnet"), "mainnet"); err == nil {
+		t.Errorf("testnet WIF should be rejected on mainnet\n")
+	}
+
+	input := PaperWalletSweepInput{
+		PrivateKey: wif,
+		Utxos: []Utxo{
+			{Address: addresses[0].Address, TxID: "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa", OutputIndex: 0, Satoshis: 60000},
+			{Address: addresses[2].Address, TxID: "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa", OutputIndex: 1, Satoshis: 40000},
+			{Address: addresses[3].Address, TxID: "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa", OutputIndex: 2, Satoshis: 20000},
+		},
+		To:      "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		FeeRate: 2,
+	}
+
+	b, _ := json.Marshal(input)
+	res, err = SweepPaperWallet(string(b))
+	if err != nil {
+		t.Fatalf("SweepPaperWallet: %v\n", err)
+	}
+
+	var swept SweepTransaction
+	json.Unmarshal([]byte(res), &swept)
+	decoded, _ := DecodeBTCTransaction(swept.HexTx, "[60000,40000,20000]", "mainnet")
+
+	var info DecodedBTCTx
+	json.Unmarshal([]byte(decoded), &info)
+	if swept.Satoshis+swept.Fee != 120000 || swept.Fee < int64(info.VSize)*input.FeeRate {
+		t.Errorf("sweep fee %d does not pay %d vbytes: %s\n", swept.Fee, info.VSize, res)
+	}
+
+	input.Utxos[0].Address = "1KKKK6N21XKo48zWKuQKXdvSsCf95ibHFa"
+	b, _ = json.Marshal(input)
+	if _, err := SweepPaperWallet(string(b)); err == nil {
+		t.Errorf("utxo of another key should be rejected\n")
+	}
+}
+
+func TestWIFCompression(t *testing.T) {
+	private := "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d"
+
+	uncompressed := HexToWIFWithCompression(private, "mainnet", false)
+	if uncompressed != "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ" {
+		t.Errorf("uncompressed WIF mismatch: %s\n", uncompressed)
+	}
+
+	compressed := HexToWIF(private)
+	if compressed != "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617" {
+		t.Errorf("compressed WIF mismatch: %s\n", compressed)
+	}
+
+	key, _ := hdwallet.HexToECDSAPrivateKey(private)
+	for wif, pkData := range map[string][]byte{
+		uncompressed: key.PubKey().SerializeUncompressed(),
+		compressed:   key.PubKey().SerializeCompressed(),
+	} {
+		res, err := ImportPrivateKey("BTC", wif, false, true)
+		if err != nil {
+			t.Fatalf("ImportPrivateKey: %v\n", err)
+		}
+
+		var wallet WalletObject
+		json.Unmarshal([]byte(res), &wallet)
+		if wallet.AddressList[0].Address != hdwallet.ToBTC(pkData, false) {
+			t.Errorf("address of %s mismatch: %s\n", wif, res)
+		}
+	}
+
+	if _, err := ImportPrivateKey("BTC", uncompressed, true, true); err == nil {
+		t.Errorf("uncompressed WIF should not have segwit address\n")
+	}
+
+	if _, err := ImportPrivateKey("BTC", "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTj", false, true); err == nil {
+		t.Errorf("WIF of bad checksum should fail\n")
+	}
+
+	if _, err := ImportPrivateKeyWithNetwork("BTC", compressed, "testnet", false, true); err == nil {
+		t.Errorf("mainnet WIF should fail on testnet\n")
+	}
+
+	//uncompressed P2PKH spends with WIF
+	legacy := hdwallet.ToBTC(key.PubKey().SerializeUncompressed(), false)
+	input := BTCTxInput{
+		Utxos: []Utxo{{
+			Address:     legacy,
+			TxID:        "bca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+			OutputIndex: 0,
+			PkScript:    pkScriptHex(legacy),
+			Satoshis:    100000,
+			Private:     uncompressed,
+		}},
+		To:  []WlTo{{To: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Satoshis: 90000}},
+		Fee: 10000,
+	}
+
+	if _, _, err := buildBTCTx(input, nil); err != nil {
+		t.Errorf("spend uncompressed P2PKH: %v\n", err)
+	}
+}
+
+func TestPayToAddrScript(t *testing.T) {
+	for _, address := range []string{"", "bc1qinvalid", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"} {
+		if _, err := getPayToAddrScript(address, &chaincfg.MainNetParams); err == nil {
+			t.Errorf("pay to %q should fail\n", address)
+		}
+
+		if _, err := GetDustThreshold(address, "mainnet", 3000); err == nil {
+			t.Errorf("dust threshold of %q should fail\n", address)
+		}
+	}
+}
+
+//pkScriptHex output script of mainnet address in hex
+func pkScriptHex(address string) string {
+	pkScript, err := getPayToAddrScript(address, &chaincfg.MainNetParams)
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(pkScript)
+}
@@ -0,0 +1,201 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	//TimelockCLTV absolute timelock by OP_CHECKLOCKTIMEVERIFY
+	TimelockCLTV = "cltv"
+	//TimelockCSV relative timelock by OP_CHECKSEQUENCEVERIFY
+	TimelockCSV = "csv"
+)
+
+//getTimelockScript build vault script
+//
+//without owner: <lock> OP_CLTV/OP_CSV OP_DROP <pubkey> OP_CHECKSIG
+//with owner:    OP_IF <owner> OP_CHECKSIG OP_ELSE <lock> OP_CLTV/OP_CSV OP_DROP <pubkey> OP_CHECKSIG OP_ENDIF
+func getTimelockScript(info *TimelockInfo) ([]byte, error) {
+	pubKey, err := hex.DecodeString(info.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode pubkey: %v", err)
+	}
+
+	if _, err := btcec.ParsePubKey(pubKey, btcec.S256()); err != nil {
+		return nil, fmt.Errorf("parse pubkey: %v", err)
+	}
+
+	if info.LockValue <= 0 || info.LockValue > 0xffffffff {
+		return nil, fmt.Errorf("lock value %d out of range", info.LockValue)
+	}
+
+	var lockOp byte
+	switch info.LockType {
+	case TimelockCLTV:
+		lockOp = txscript.OP_CHECKLOCKTIMEVERIFY
+	case TimelockCSV:
+		if info.LockValue&int64(wire.SequenceLockTimeDisabled) != 0 {
+			return nil, errors.New("csv lock value has disable flag set")
+		}
+		lockOp = txscript.OP_CHECKSEQUENCEVERIFY
+	default:
+		return nil, fmt.Errorf("lock type %s is not support", info.LockType)
+	}
+
+	b := txscript.NewScriptBuilder()
+	if info.OwnerPubKey != "" {
+		ownerPubKey, err := hex.DecodeString(info.OwnerPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("decode owner pubkey: %v", err)
+		}
+
+		if _, err := btcec.ParsePubKey(ownerPubKey, btcec.S256()); err != nil {
+			return nil, fmt.Errorf("parse owner pubkey: %v", err)
+		}
+
+		b.AddOp(txscript.OP_IF)
+		b.AddData(ownerPubKey)
+		b.AddOp(txscript.OP_CHECKSIG)
+		b.AddOp(txscript.OP_ELSE)
+	}
+
+	b.AddInt64(info.LockValue)
+	b.AddOp(lockOp)
+	b.AddOp(txscript.OP_DROP)
+	b.AddData(pubKey)
+	b.AddOp(txscript.OP_CHECKSIG)
+
+	if info.OwnerPubKey != "" {
+		b.AddOp(txscript.OP_ENDIF)
+	}
+
+	return b.Script()
+}
+
+//getScriptAddress p2sh or p2wsh address of script
+func getScriptAddress(script []byte, scriptType string, params *chaincfg.Params) (btcutil.Address, error) {
+	switch scriptType {
+	case "p2sh":
+		return btcutil.NewAddressScriptHash(script, params)
+	case "p2wsh":
+		scriptHash := sha256.Sum256(script)
+		return btcutil.NewAddressWitnessScriptHash(scriptHash[:], params)
+	default:
+		return nil, fmt.Errorf("script type %s is not support", scriptType)
+	}
+}
+
+//timelockBranch get the OP_IF selector of vault script for key, nil if script has no branch
+func timelockBranch(script, pubKey []byte) [][]byte {
+	if len(script) == 0 || script[0] != txscript.OP_IF {
+		return nil
+	}
+
+	//owner key is the first push after OP_IF
+	if len(script) > 2+len(pubKey) && int(script[1]) == len(pubKey) &&
+		bytes.Equal(script[2:2+len(pubKey)], pubKey) {
+		return [][]byte{{1}}
+	}
+
+	return [][]byte{{}}
+}
+
+//signScriptInput sign input spending p2sh or p2wsh script path
+func signScriptInput(tx *wire.MsgTx, i int, utxo *Utxo, prevOut *wire.TxOut, signer hdwallet.Signer, keyID string,
+	pkData []byte, hashType txscript.SigHashType, txSigHashes *txscript.TxSigHashes) error {
+	script, err := hex.DecodeString(utxo.Script)
+	if err != nil {
+		return fmt.Errorf("decode script: %v", err)
+	}
+
+	branch := timelockBranch(script, pkData)
+
+	switch txscript.GetScriptClass(prevOut.PkScript) {
+	case txscript.WitnessV0ScriptHashTy:
+		sig, err := rawTxInWitnessSignature(tx, txSigHashes, i,
+			prevOut.Value, script, hashType, signer, keyID)
+		if err != nil {
+			return err
+		}
+
+		witness := wire.TxWitness{sig}
+		witness = append(witness, branch...)
+		witness = append(witness, script)
+		tx.TxIn[i].Witness = witness
+
+	case txscript.ScriptHashTy:
+		sig, err := rawTxInSignature(tx, i, script, hashType, signer, keyID)
+		if err != nil {
+			return err
+		}
+
+		b := txscript.NewScriptBuilder()
+		b.AddData(sig)
+		for _, item := range branch {
+			b.AddData(item)
+		}
+		b.AddData(script)
+
+		scriptsig, err := b.Script()
+		if err != nil {
+			return err
+		}
+
+		tx.TxIn[i].SignatureScript = scriptsig
+
+	default:
+		return errors.New("pkscript of script path must be p2sh or p2wsh")
+	}
+
+	return nil
+}
+
+//CreateTimelockAddress create timelocked vault address, info is json of TimelockInfo
+func CreateTimelockAddress(info string) (string, error) {
+	var in TimelockInfo
+	err := json.Unmarshal([]byte(info), &in)
+	if err != nil {
+		return "", err
+	}
+
+	params, err := hdwallet.GetNetParams(in.Network)
+	if err != nil {
+		return "", err
+	}
+
+	script, err := getTimelockScript(&in)
+	if err != nil {
+		return "", err
+	}
+
+	address, err := getScriptAddress(script, in.ScriptType, params)
+	if err != nil {
+		return "", err
+	}
+
+	obj := &TimelockAddress{
+		Address:    address.EncodeAddress(),
+		Script:     hex.EncodeToString(script),
+		ScriptType: in.ScriptType,
+	}
+
+	strObj, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	return string(strObj), nil
+}
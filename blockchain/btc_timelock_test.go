@@ -0,0 +1,151 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+)
+
+func TestTimelockVault(t *testing.T) {
+	owner := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	heir := "1111111111111111111111111111111111111111111111111111111111111111"
+
+	ownerKey, _ := hdwallet.HexToECDSAPrivateKey(owner)
+	heirKey, _ := hdwallet.HexToECDSAPrivateKey(heir)
+
+	for _, scriptType := range []string{"p2sh", "p2wsh"} {
+		info := TimelockInfo{
+			PubKey:      hex.EncodeToString(heirKey.PubKey().SerializeCompressed()),
+			OwnerPubKey: hex.EncodeToString(ownerKey.PubKey().SerializeCompressed()),
+			LockType:    TimelockCLTV,
+			LockValue:   600000,
+			ScriptType:  scriptType,
+		}
+		infoBytes, _ := json.Marshal(info)
+
+		res, err := CreateTimelockAddress(string(infoBytes))
+		if err != nil {
+			t.Fatalf("CreateTimelockAddress: %v\n", err)
+		}
+		fmt.Printf("%s vault: %v\n", scriptType, res)
+
+		var vault TimelockAddress
+		json.Unmarshal([]byte(res), &vault)
+
+		spends := []struct {
+			private  string
+			lockTime uint32
+			ok       bool
+		}{
+			{owner, 0, true},
+			{heir, 599999, false},
+			{heir, 600000, true},
+		}
+
+		for _, spend := range spends {
+			input := BTCTxInput{
+				Utxos: []Utxo{{
+					Address:     vault.Address,
+					TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+					OutputIndex: 0,
+					PkScript:    pkScriptHex(vault.Address),
+					Satoshis:    100000,
+					Private:     spend.private,
+					Script:      vault.Script,
+				}},
+				To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 90000}},
+				ChangeAddress: vault.Address,
+				Fee:           10000,
+				LockTime:      spend.lockTime,
+			}
+
+			_, _, err := buildBTCTx(input, nil)
+			if (err == nil) != spend.ok {
+				t.Errorf("%s spend with locktime %d: %v\n", scriptType, spend.lockTime, err)
+			}
+		}
+	}
+}
+
+func TestTimelockCSV(t *testing.T) {
+	owner := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+	heir := "1111111111111111111111111111111111111111111111111111111111111111"
+
+	ownerKey, _ := hdwallet.HexToECDSAPrivateKey(owner)
+	heirKey, _ := hdwallet.HexToECDSAPrivateKey(heir)
+
+	sequence := func(v uint32) *uint32 {
+		return &v
+	}
+
+	for _, scriptType := range []string{"p2sh", "p2wsh"} {
+		info := TimelockInfo{
+			PubKey:      hex.EncodeToString(heirKey.PubKey().SerializeCompressed()),
+			OwnerPubKey: hex.EncodeToString(ownerKey.PubKey().SerializeCompressed()),
+			LockType:    TimelockCSV,
+			LockValue:   144,
+			ScriptType:  scriptType,
+		}
+		infoBytes, _ := json.Marshal(info)
+
+		res, err := CreateTimelockAddress(string(infoBytes))
+		if err != nil {
+			t.Fatalf("CreateTimelockAddress: %v\n", err)
+		}
+
+		var vault TimelockAddress
+		json.Unmarshal([]byte(res), &vault)
+
+		spends := []struct {
+			private  string
+			sequence *uint32
+			ok       bool
+		}{
+			{owner, nil, true},
+			{owner, sequence(0), true},
+			{heir, nil, false},
+			{heir, sequence(0), false},
+			{heir, sequence(143), false},
+			{heir, sequence(144), true},
+		}
+
+		for _, spend := range spends {
+			input := BTCTxInput{
+				Utxos: []Utxo{{
+					Address:     vault.Address,
+					TxID:        "4ca3ab297341bec8603f16a747068975531339bf72469b40bc89cfd54eeb56fa",
+					OutputIndex: 0,
+					PkScript:    pkScriptHex(vault.Address),
+					Satoshis:    100000,
+					Private:     spend.private,
+					Script:      vault.Script,
+					Sequence:    spend.sequence,
+				}},
+				To:            []WlTo{{To: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Satoshis: 90000}},
+				ChangeAddress: vault.Address,
+				Fee:           10000,
+			}
+
+			tx, _, err := buildBTCTx(input, nil)
+			if (err == nil) != spend.ok {
+				t.Errorf("%s spend with sequence %v: %v\n", scriptType, spend.sequence, err)
+				continue
+			}
+
+			if err != nil {
+				continue
+			}
+
+			if spend.sequence == nil {
+				if tx.Version != 1 || tx.TxIn[0].Sequence != CurrentTxInSequenceNum {
+					t.Errorf("%s spend without sequence: version %d sequence %d\n", scriptType, tx.Version, tx.TxIn[0].Sequence)
+				}
+			} else if tx.Version != 2 || tx.TxIn[0].Sequence != *spend.sequence {
+				t.Errorf("%s spend with sequence %d: version %d sequence %d\n", scriptType, *spend.sequence, tx.Version, tx.TxIn[0].Sequence)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package aave
 //AaveApprover approve input
 type AaveApprover struct {
 	HexPrivateKey string `json:"HexPrivateKey"`
+	KeyID         string `json:"KeyID"` //key of signer when signed with signer
 	ToAddress     string `json:"ToAddress"`
 	Value         uint64 `json:"Value"`
 	Nonce         uint64 `json:"Nonce"`
@@ -17,6 +18,7 @@ type AaveApprover struct {
 //AaveDepositer deposit input
 type AaveDepositer struct {
 	HexPrivateKey string `json:"HexPrivateKey"`
+	KeyID         string `json:"KeyID"` //key of signer when signed with signer
 	ToAddress     string `json:"ToAddress"`
 	Value         uint64 `json:"Value"`
 	Nonce         uint64 `json:"Nonce"`
@@ -33,6 +35,7 @@ type AaveDepositer struct {
 //AaveRedeemer redeem input
 type AaveRedeemer struct {
 	HexPrivateKey string `json:"HexPrivateKey"`
+	KeyID         string `json:"KeyID"` //key of signer when signed with signer
 	ToAddress     string `json:"ToAddress"`
 	Value         uint64 `json:"Value"`
 	Nonce         uint64 `json:"Nonce"`
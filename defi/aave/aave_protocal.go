@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"golang.org/x/crypto/sha3"
 )
@@ -46,14 +47,39 @@ func GetMethodID(methodName string) []byte {
 //MakeTransaction construct eth transaction
 func MakeTransaction(privateKeyString, to string, value, nonce, gasLimit, gasPrice, chainID uint64, data []byte) (*types.Transaction, error) {
 	//private key
-	privateKey, err := crypto.HexToECDSA(privateKeyString)
+	signer, err := hdwallet.NewHexKeySigner(privateKeyString)
 	if err != nil {
 		return nil, fmt.Errorf("HexToECDSA: %v", err)
 	}
 
+	return MakeTransactionWithSigner(signer, "", to, value, nonce, gasLimit, gasPrice, chainID, data)
+}
+
+//MakeTransactionWithSigner construct eth transaction signed by signer with keyID
+func MakeTransactionWithSigner(signer hdwallet.Signer, keyID, to string, value, nonce, gasLimit, gasPrice, chainID uint64, data []byte) (*types.Transaction, error) {
 	tx := types.NewTransaction(nonce, common.HexToAddress(to), big.NewInt(int64(value)), gasLimit, big.NewInt(int64(gasPrice)), data)
 
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(int64(chainID))), privateKey)
+	txSigner := types.NewEIP155Signer(big.NewInt(int64(chainID)))
+	hash := txSigner.Hash(tx)
+
+	pubKey, err := signer.PublicKey(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("signer public key: %v", err)
+	}
+
+	sig, err := signer.SignDigest(keyID, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("SignTx error: %v", err)
+	}
+
+	//compact signature is [27 + 4 + v] || r || s, ethereum wants r || s || v
+	compact, err := hdwallet.CompactSignature(pubKey, hash[:], sig)
+	if err != nil {
+		return nil, fmt.Errorf("SignTx error: %v", err)
+	}
+	ethSig := append(compact[1:], compact[0]-27-4)
+
+	signedTx, err := tx.WithSignature(txSigner, ethSig)
 	if err != nil {
 		return nil, fmt.Errorf("SignTx error: %v", err)
 	}
@@ -61,8 +87,31 @@ func MakeTransaction(privateKeyString, to string, value, nonce, gasLimit, gasPri
 	return signedTx, nil
 }
 
+//makeTransaction sign with signer and keyID, or with hex private key when signer is nil
+func makeTransaction(hexPrivateKey, keyID string, signer hdwallet.Signer, to string, value, nonce, gasLimit, gasPrice, chainID uint64, data []byte) (*types.Transaction, error) {
+	if signer == nil {
+		return MakeTransaction(hexPrivateKey, to, value, nonce, gasLimit, gasPrice, chainID, data)
+	}
+
+	return MakeTransactionWithSigner(signer, keyID, to, value, nonce, gasLimit, gasPrice, chainID, data)
+}
+
 //Approve Approve ERC20
 func Approve(input *AaveApprover) (string, error) {
+	return approve(input, nil)
+}
+
+//ApproveWithSigner like Approve, transaction is signed by signer with input.KeyID
+func ApproveWithSigner(input *AaveApprover, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	return approve(input, signer)
+}
+
+//approve signed by signer with input.KeyID, or by input.HexPrivateKey when signer is nil
+func approve(input *AaveApprover, signer hdwallet.Signer) (string, error) {
 	//make data
 	//spender
 	toAddr := common.HexToAddress(input.LendingPoolCoreAddress)
@@ -79,7 +128,7 @@ func Approve(input *AaveApprover) (string, error) {
 	data = append(data, paddedToAddress...)
 	data = append(data, paddedValue...)
 
-	signedTx, err := MakeTransaction(input.HexPrivateKey, input.ToAddress, input.Value, input.Nonce, input.GasLimit, input.GasPrice, input.ChainID, data)
+	signedTx, err := makeTransaction(input.HexPrivateKey, input.KeyID, signer, input.ToAddress, input.Value, input.Nonce, input.GasLimit, input.GasPrice, input.ChainID, data)
 	if err != nil {
 		return "", fmt.Errorf("Approve %v", err)
 	}
@@ -98,6 +147,20 @@ func Approve(input *AaveApprover) (string, error) {
 
 //Deposit deposit on Aave protocal
 func Deposit(input *AaveDepositer) (string, error) {
+	return deposit(input, nil)
+}
+
+//DepositWithSigner like Deposit, transaction is signed by signer with input.KeyID
+func DepositWithSigner(input *AaveDepositer, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	return deposit(input, signer)
+}
+
+//deposit signed by signer with input.KeyID, or by input.HexPrivateKey when signer is nil
+func deposit(input *AaveDepositer, signer hdwallet.Signer) (string, error) {
 	//spender
 	paddedReserveAddress := common.LeftPadBytes(common.HexToAddress(input.ReserveAddress).Bytes(), 32)
 
@@ -115,7 +178,7 @@ func Deposit(input *AaveDepositer) (string, error) {
 	data = append(data, paddedAmount...)
 	data = append(data, paddedReferCode...)
 
-	signedTx, err := MakeTransaction(input.HexPrivateKey, input.ToAddress, input.Value, input.Nonce, input.GasLimit, input.GasPrice, input.ChainID, data)
+	signedTx, err := makeTransaction(input.HexPrivateKey, input.KeyID, signer, input.ToAddress, input.Value, input.Nonce, input.GasLimit, input.GasPrice, input.ChainID, data)
 	if err != nil {
 		return "", fmt.Errorf("Deposit %v", err)
 	}
@@ -134,6 +197,20 @@ func Deposit(input *AaveDepositer) (string, error) {
 
 //Redeem redeem balance
 func Redeem(input *AaveRedeemer) (string, error) {
+	return redeem(input, nil)
+}
+
+//RedeemWithSigner like Redeem, transaction is signed by signer with input.KeyID
+func RedeemWithSigner(input *AaveRedeemer, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	return redeem(input, signer)
+}
+
+//redeem signed by signer with input.KeyID, or by input.HexPrivateKey when signer is nil
+func redeem(input *AaveRedeemer, signer hdwallet.Signer) (string, error) {
 
 	//value
 	paddedAmount := common.LeftPadBytes(big.NewInt(input.Amount).Bytes(), 32)
@@ -144,7 +221,7 @@ func Redeem(input *AaveRedeemer) (string, error) {
 	data = append(data, methodID...)
 	data = append(data, paddedAmount...)
 
-	signedTx, err := MakeTransaction(input.HexPrivateKey, input.ToAddress, input.Value, input.Nonce, input.GasLimit, input.GasPrice, input.ChainID, data)
+	signedTx, err := makeTransaction(input.HexPrivateKey, input.KeyID, signer, input.ToAddress, input.Value, input.Nonce, input.GasLimit, input.GasPrice, input.ChainID, data)
 	if err != nil {
 		return "", fmt.Errorf("Redeem %v", err)
 	}
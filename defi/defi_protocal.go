@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/tsfdsong/atoken-app-sdk/defi/aave"
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 )
 
 const (
@@ -15,6 +16,19 @@ const (
 
 //DefiProtocal cmdType: operator code;data: json string of input parameter
 func DefiProtocal(cmdType int, data string) (string, error) {
+	return defiProtocal(cmdType, data, nil)
+}
+
+//DefiProtocalWithSigner like DefiProtocal, transaction is signed by signer with KeyID of input
+func DefiProtocalWithSigner(cmdType int, data string, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	return defiProtocal(cmdType, data, signer)
+}
+
+func defiProtocal(cmdType int, data string, signer hdwallet.Signer) (string, error) {
 	switch cmdType {
 	case tDefiApprove:
 		{
@@ -24,7 +38,11 @@ func DefiProtocal(cmdType int, data string) (string, error) {
 				return "", fmt.Errorf("Approve unmarshal, %v", err)
 			}
 
-			return aave.Approve(&input)
+			if signer == nil {
+				return aave.Approve(&input)
+			}
+
+			return aave.ApproveWithSigner(&input, signer)
 		}
 	case tDefiAaveDeposit:
 		{
@@ -34,7 +52,11 @@ func DefiProtocal(cmdType int, data string) (string, error) {
 				return "", fmt.Errorf("Deposit unmarshal, %v", err)
 			}
 
-			return aave.Deposit(&input)
+			if signer == nil {
+				return aave.Deposit(&input)
+			}
+
+			return aave.DepositWithSigner(&input, signer)
 		}
 	case tDefiAaveRedeem:
 		{
@@ -44,7 +66,11 @@ func DefiProtocal(cmdType int, data string) (string, error) {
 				return "", fmt.Errorf("Redeem unmarshal, %v", err)
 			}
 
-			return aave.Redeem(&input)
+			if signer == nil {
+				return aave.Redeem(&input)
+			}
+
+			return aave.RedeemWithSigner(&input, signer)
 		}
 	default:
 		return "", fmt.Errorf("DefiProtocal not support %v operator", cmdType)
@@ -3,12 +3,15 @@ package defi
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/tsfdsong/atoken-app-sdk/defi/aave"
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 )
 
 func TestDefiProtocal(t *testing.T) {
@@ -96,3 +99,56 @@ func TestDefiProtocalOfRedeem(t *testing.T) {
 
 	// fmt.Printf("DefiProtocal tx, %v\n", txData)
 }
+
+func TestDefiProtocalWithSigner(t *testing.T) {
+	private := "6226d8f8c181622d82a84e2d36e4c66c49f07c36cbf001b9b78abeb8ba313d41"
+
+	depositData := aave.AaveDepositer{
+		HexPrivateKey:  private,
+		ToAddress:      "0x398ec7346dcd622edc5ae82352f02be94c62d119",
+		Nonce:          7,
+		GasPrice:       27000000000,
+		GasLimit:       300000,
+		ChainID:        1,
+		ReserveAddress: "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		Amount:         1000000,
+	}
+	data, _ := json.Marshal(depositData)
+
+	expected, err := DefiProtocal(1, string(data))
+	if err != nil {
+		t.Fatalf("DefiProtocal: %v\n", err)
+	}
+
+	keySigner := hdwallet.NewKeySigner()
+	keySigner.AddHexKey("m/44'/60'/0'/0/0", private)
+	signer := hdwallet.NewMockSigner(keySigner)
+
+	depositData.HexPrivateKey = ""
+	depositData.KeyID = "m/44'/60'/0'/0/0"
+	data, _ = json.Marshal(depositData)
+
+	tx, err := DefiProtocalWithSigner(1, string(data), signer)
+	if err != nil {
+		t.Fatalf("DefiProtocalWithSigner: %v\n", err)
+	}
+
+	if tx != expected || signer.SignCount() != 1 || signer.SignedKeyID(0) != depositData.KeyID {
+		t.Errorf("signer transaction %v, expected %v\n", tx, expected)
+	}
+
+	txData, _ := hexutil.Decode(tx)
+	var txRaw types.Transaction
+	rlp.DecodeBytes(txData, &txRaw)
+
+	sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), &txRaw)
+	key, _ := crypto.HexToECDSA(private)
+	if err != nil || sender != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("sender %v of signer transaction: %v\n", sender.Hex(), err)
+	}
+
+	signer.Fail("user canceled")
+	if _, err := DefiProtocalWithSigner(1, string(data), signer); err == nil {
+		t.Errorf("signer error should fail the transaction\n")
+	}
+}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
-	"github.com/mr-tron/base58/base58"
 )
 
 // DerivationPath represents the computer friendly version of a hierarchical
@@ -141,15 +140,13 @@ func HexToECDSAPublicKey(hexkey string) (*btcec.PublicKey, error) {
 	return esdsaPublicKeyy, nil
 }
 
-// WIFToECDSAPublicKey parses a secp256k1 public key.
+// WIFToECDSAPublicKey parses a secp256k1 public key of WIF on any network,
+// see DecodeWIF for the compressed flag.
 func WIFToECDSAPublicKey(hexkey string) (*btcec.PublicKey, error) {
-	priBytes, err := base58.Decode(hexkey)
+	key, _, err := DecodeWIF(hexkey, nil)
 	if err != nil {
-		return nil, errors.New("invalid wif string")
+		return nil, err
 	}
 
-	newBytes := priBytes[1 : len(priBytes)-4]
-	_, esdsaPublicKeyy := btcec.PrivKeyFromBytes(btcec.S256(), newBytes)
-
-	return esdsaPublicKeyy, nil
-}
\ No newline at end of file
+	return key.PubKey(), nil
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
-	"github.com/mr-tron/base58"
 	"github.com/tyler-smith/go-bip39"
 )
 
@@ -19,6 +18,7 @@ type Wallet struct {
 	Mnemonic  string
 	MasterKey *hdkeychain.ExtendedKey
 	CoinType  string
+	Params    *chaincfg.Params
 
 	Entropy string
 	Seed    string
@@ -46,8 +46,18 @@ func CreateMnemonic(mnemonic string) (string, error) {
 	return mnemonic, nil
 }
 
-//NewWallet return a new wallet from a BIP-39 mnemonic
+//NewWallet return a new mainnet wallet from a BIP-39 mnemonic
 func NewWallet(mnemonic, coinType string) (*Wallet, error) {
+	return NewWalletWithNetwork(mnemonic, coinType, "mainnet")
+}
+
+//NewWalletWithNetwork return a new wallet of network(mainnet, testnet, signet or regtest) from a BIP-39 mnemonic
+func NewWalletWithNetwork(mnemonic, coinType, network string) (*Wallet, error) {
+	params, err := GetNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
 	if mnemonic == "" {
 		return nil, errors.New("mnemonic is required")
 	}
@@ -68,7 +78,7 @@ func NewWallet(mnemonic, coinType string) (*Wallet, error) {
 	}
 	hexSeed := hex.EncodeToString(seed)
 
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	masterKey, err := hdkeychain.NewMaster(seed, params)
 	if err != nil {
 		return nil, err
 	}
@@ -77,13 +87,14 @@ func NewWallet(mnemonic, coinType string) (*Wallet, error) {
 		Mnemonic:  mnemonic,
 		MasterKey: masterKey,
 		CoinType:  coinType,
+		Params:    params,
 		Entropy:   hexEntropy,
 		Seed:      hexSeed,
 	}, nil
 }
 
-// DerivePrivateKey derives the private key of the derivation path.
-func (w *Wallet) DerivePrivateKey(path string) (*btcec.PrivateKey, error) {
+// DeriveExtendedKey derives the extended key of the derivation path.
+func (w *Wallet) DeriveExtendedKey(path string) (*hdkeychain.ExtendedKey, error) {
 	key := w.MasterKey
 
 	dpath, err := ParseDerivationPath(path)
@@ -98,6 +109,16 @@ func (w *Wallet) DerivePrivateKey(path string) (*btcec.PrivateKey, error) {
 		}
 	}
 
+	return key, nil
+}
+
+// DerivePrivateKey derives the private key of the derivation path.
+func (w *Wallet) DerivePrivateKey(path string) (*btcec.PrivateKey, error) {
+	key, err := w.DeriveExtendedKey(path)
+	if err != nil {
+		return nil, err
+	}
+
 	privateKeyECDSA, err := key.ECPrivKey()
 	if err != nil {
 		return nil, err
@@ -118,6 +139,20 @@ func (w *Wallet) DerivePublicKey(path string) (*btcec.PublicKey, error) {
 	return publicKeyECDSA, nil
 }
 
+//getCoinIndex return bip44 coin index, all test networks of BTC use coin index 1
+func (w *Wallet) getCoinIndex(coinType string) (int, error) {
+	coinIndex, err := GetCoinIndex(coinType)
+	if err != nil {
+		return 0, err
+	}
+
+	if coinType == "BTC" && !IsMainNet(w.Params) {
+		coinIndex = 1
+	}
+
+	return coinIndex, nil
+}
+
 //GetWalletID get wallet id
 func (w *Wallet) GetWalletID() (string, error) {
 	masterKey := w.MasterKey
@@ -145,7 +180,7 @@ func (w *Wallet) GetWalletID() (string, error) {
 
 //GetPrivateKey get hex private
 func (w *Wallet) GetPrivateKey(coinType string, index int, isSegwit bool) (string, error) {
-	coinIndex, err := GetCoinIndex(coinType)
+	coinIndex, err := w.getCoinIndex(coinType)
 	if err != nil {
 		return "", err
 	}
@@ -171,7 +206,7 @@ func (w *Wallet) GetPrivateKey(coinType string, index int, isSegwit bool) (strin
 
 //GetWIFPrivateKey get WIF private key
 func (w *Wallet) GetWIFPrivateKey(coinType string, index int, isSegwit bool) (string, error) {
-	coinIndex, err := GetCoinIndex(coinType)
+	coinIndex, err := w.getCoinIndex(coinType)
 	if err != nil {
 		return "", err
 	}
@@ -188,19 +223,14 @@ func (w *Wallet) GetWIFPrivateKey(coinType string, index int, isSegwit bool) (st
 		return "", err
 	}
 
-	priKey := btcec.PrivateKey(*esdsaPrivateKey)
-	priBytes := priKey.Serialize()
-
-	//0.add version 0x80
-	versionPayload := append([]byte{byte(0x80)}, priBytes...)
-
-	checksumBytes := CheckSum(versionPayload)
-
-	fullPayload := append(versionPayload, checksumBytes...)
-
-	wifPriKey := base58.Encode(fullPayload)
-
-	return wifPriKey, nil
+	switch coinType {
+	case "BTC", "LTC":
+		//addresses of HD wallet are of compressed public key
+		return EncodeWIF(esdsaPrivateKey, true, w.Params), nil
+	default:
+		//EOS and VEX keys are uncompressed mainnet WIF whatever the network
+		return EncodeWIF(esdsaPrivateKey, false, &chaincfg.MainNetParams), nil
+	}
 }
 
 //GetKeyAndAddress get hex publickey and address
@@ -217,7 +247,7 @@ func (w *Wallet) GetKeyAndAddress(coinType string, index int, isSegwit bool) (st
 
 	_, ecdsaPubKey := btcec.PrivKeyFromBytes(btcec.S256(), priBytes)
 
-	return PublicKeyToAddress(coinType, ecdsaPubKey, isSegwit)
+	return PublicKeyToAddressWithParams(coinType, ecdsaPubKey, isSegwit, w.Params)
 }
 
 //GetKeyAndAddressSegwit get hex publickey and segwit address
@@ -241,7 +271,57 @@ func (w *Wallet) GetKeyAndAddressSegwit(coinType string, index int) (string, str
 	segwitPublicKey := hex.EncodeToString(secH160bytes)
 
 	//segwit address
-	segwitAddress := ToBTC(pubkeyBytes, true)
+	segwitAddress := ToBTCWithParams(pubkeyBytes, true, w.Params)
 
 	return segwitPublicKey, segwitAddress, nil
 }
+
+//GetMultisigXPub get the BIP-48 account extended public key of a multisig cosigner,
+//p2sh uses the non-standard path m/45'/<account>
+func (w *Wallet) GetMultisigXPub(coinType string, account int, scriptType string) (string, error) {
+	coinIndex, err := w.getCoinIndex(coinType)
+	if err != nil {
+		return "", err
+	}
+
+	bipPath, err := multisigAccountPath(coinIndex, account, scriptType)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := w.DeriveExtendedKey(bipPath)
+	if err != nil {
+		return "", err
+	}
+
+	pubKey, err := key.Neuter()
+	if err != nil {
+		return "", err
+	}
+
+	return pubKey.String(), nil
+}
+
+//GetMultisigPrivateKey get hex private key of a multisig cosigner at change/index
+func (w *Wallet) GetMultisigPrivateKey(coinType string, account int, scriptType string, change, index int) (string, error) {
+	coinIndex, err := w.getCoinIndex(coinType)
+	if err != nil {
+		return "", err
+	}
+
+	bipPath, err := multisigAccountPath(coinIndex, account, scriptType)
+	if err != nil {
+		return "", err
+	}
+
+	esdsaPrivateKey, err := w.DerivePrivateKey(fmt.Sprintf("%s/%d/%d", bipPath, change, index))
+	if err != nil {
+		return "", err
+	}
+
+	priKey := btcec.PrivateKey(*esdsaPrivateKey)
+	priBytes := priKey.Serialize()
+	priKeyHex := hex.EncodeToString(priBytes)
+
+	return priKeyHex, nil
+}
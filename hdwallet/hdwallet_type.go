@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 )
 
@@ -34,8 +35,30 @@ func GetCoinIndex(coinType string) (index int, err error) {
 	return index, err
 }
 
-//PublicKeyToAddress convert public key to address
+//multisigAccountPath return the account path of a multisig wallet. DOC: https://github.com/bitcoin/bips/blob/master/bip-0048.mediawiki
+//
+//BIP-48 has no legacy script type, p2sh uses the non-standard path m/45'/<account>. It is not BIP-45:
+//the account is not the cosigner index of the sorted public keys, so other BIP-45 wallets derive other keys
+func multisigAccountPath(coinIndex, account int, scriptType string) (string, error) {
+	switch scriptType {
+	case "p2sh":
+		return fmt.Sprintf("m/45'/%d", account), nil
+	case "p2sh-p2wsh":
+		return fmt.Sprintf("m/48'/%d'/%d'/1'", coinIndex, account), nil
+	case "p2wsh":
+		return fmt.Sprintf("m/48'/%d'/%d'/2'", coinIndex, account), nil
+	default:
+		return "", fmt.Errorf("multisig script type %s is not support", scriptType)
+	}
+}
+
+//PublicKeyToAddress convert public key to mainnet address
 func PublicKeyToAddress(coinType string, pubkey *btcec.PublicKey, isSegwit bool) (key string, addr string, err error) {
+	return PublicKeyToAddressWithParams(coinType, pubkey, isSegwit, &chaincfg.MainNetParams)
+}
+
+//PublicKeyToAddressWithParams convert public key to address, params is used by BTC
+func PublicKeyToAddressWithParams(coinType string, pubkey *btcec.PublicKey, isSegwit bool, params *chaincfg.Params) (key string, addr string, err error) {
 	pubkeyBytes := pubkey.SerializeCompressed()
 
 	switch coinType {
@@ -48,11 +71,11 @@ func PublicKeyToAddress(coinType string, pubkey *btcec.PublicKey, isSegwit bool)
 				key = hex.EncodeToString(secH160bytes)
 
 				//segwit address
-				addr = ToBTC(pubkeyBytes, true)
+				addr = ToBTCWithParams(pubkeyBytes, true, params)
 
 			} else {
 				key = hex.EncodeToString(pubkeyBytes)
-				addr = ToBTC(pubkeyBytes, false)
+				addr = ToBTCWithParams(pubkeyBytes, false, params)
 			}
 		}
 	case "LTC":
@@ -22,11 +22,16 @@ func CheckSum(payload []byte) []byte {
 	return secondSHA[:addressChecksumLen]
 }
 
-//ToBTC convert public key to BTC address of P2PKH
+//ToBTC convert public key to BTC mainnet address of P2PKH
 func ToBTC(pubkey []byte, isSegwit bool) string {
+	return ToBTCWithParams(pubkey, isSegwit, &chaincfg.MainNetParams)
+}
+
+//ToBTCWithParams convert public key to BTC address of P2PKH on network of params
+func ToBTCWithParams(pubkey []byte, isSegwit bool, params *chaincfg.Params) string {
 	if !isSegwit {
 		//new method
-		P2PKHAddr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubkey), &chaincfg.MainNetParams)
+		P2PKHAddr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubkey), params)
 		if err != nil {
 			return ""
 		}
@@ -38,7 +43,7 @@ func ToBTC(pubkey []byte, isSegwit bool) string {
 
 	//P2Sh with P2WPKH
 	address, err := btcutil.NewAddressWitnessPubKeyHash(
-		btcutil.Hash160(pubkey), &chaincfg.MainNetParams)
+		btcutil.Hash160(pubkey), params)
 	if err != nil {
 		return ""
 	}
@@ -49,7 +54,7 @@ func ToBTC(pubkey []byte, isSegwit bool) string {
 	}
 
 	scriptAddr, err := btcutil.NewAddressScriptHash(
-		pkScript, &chaincfg.MainNetParams)
+		pkScript, params)
 	if err != nil {
 		return ""
 	}
@@ -0,0 +1,35 @@
+package hdwallet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+//signetParams signet shares address and key prefixes with testnet3
+var signetParams = func() chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "signet"
+	return params
+}()
+
+//GetNetParams return bitcoin chain params of network: mainnet, testnet, signet or regtest
+func GetNetParams(network string) (*chaincfg.Params, error) {
+	switch network {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &signetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	default:
+		return nil, fmt.Errorf("network %s is not support", network)
+	}
+}
+
+//IsMainNet check whether params is bitcoin mainnet
+func IsMainNet(params *chaincfg.Params) bool {
+	return params.Net == chaincfg.MainNetParams.Net
+}
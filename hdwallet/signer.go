@@ -0,0 +1,323 @@
+package hdwallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	btcecv2 "github.com/btcsuite/btcd/btcec/v2"
+)
+
+//Signer signs digests with a secp256k1 key kept outside of the sdk, e.g. a Secure Enclave or
+//Android Keystore bridge or a remote HSM. keyID names the key on the signer side, like a derivation path.
+type Signer interface {
+	//PublicKey compressed public key of keyID
+	PublicKey(keyID string) ([]byte, error)
+	//SignDigest sign 32 bytes digest, result is 64 bytes r || s
+	SignDigest(keyID string, digest []byte) ([]byte, error)
+}
+
+//CanonicalSigner Signer able to sign a digest again with another nonce, needed by chains like EOSIO
+//which only accept canonical signatures of a fixed transaction
+type CanonicalSigner interface {
+	Signer
+	//SignDigestWithNonce sign 32 bytes digest with counter as extra RFC6979 entropy, counter 0 is
+	//the signature of SignDigest, result is 64 bytes r || s
+	SignDigestWithNonce(keyID string, digest []byte, counter uint32) ([]byte, error)
+}
+
+//KeySigner in-memory Signer of private keys
+type KeySigner struct {
+	mu   sync.RWMutex
+	keys map[string]*btcec.PrivateKey
+}
+
+//NewKeySigner create empty in-memory signer
+func NewKeySigner() *KeySigner {
+	return &KeySigner{
+		keys: make(map[string]*btcec.PrivateKey),
+	}
+}
+
+//NewHexKeySigner in-memory signer of a single hex private key with empty keyID
+func NewHexKeySigner(hexKey string) (*KeySigner, error) {
+	s := NewKeySigner()
+	if err := s.AddHexKey("", hexKey); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+//AddHexKey add hex private key as keyID
+func (s *KeySigner) AddHexKey(keyID, hexKey string) error {
+	if len(hexKey) != 64 {
+		return fmt.Errorf("private key should be 32 bytes, got %d hex chars", len(hexKey))
+	}
+
+	key, err := HexToECDSAPrivateKey(hexKey)
+	if err != nil {
+		return err
+	}
+
+	s.AddKey(keyID, key)
+	return nil
+}
+
+//AddKey add private key as keyID
+func (s *KeySigner) AddKey(keyID string, key *btcec.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[keyID] = key
+}
+
+func (s *KeySigner) getKey(keyID string) (*btcec.PrivateKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", keyID)
+	}
+
+	return key, nil
+}
+
+//PublicKey compressed public key of keyID
+func (s *KeySigner) PublicKey(keyID string) ([]byte, error) {
+	key, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.PubKey().SerializeCompressed(), nil
+}
+
+//SignDigest RFC6979 signature of digest, result is 64 bytes r || s
+func (s *KeySigner) SignDigest(keyID string, digest []byte) ([]byte, error) {
+	key, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest should be 32 bytes, got %d", len(digest))
+	}
+
+	sig, err := key.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 64)
+	copy(result[32-len(sig.R.Bytes()):32], sig.R.Bytes())
+	copy(result[64-len(sig.S.Bytes()):], sig.S.Bytes())
+
+	return result, nil
+}
+
+//SignDigestWithNonce RFC6979 signature of digest with counter as extra entropy, result is 64 bytes r || s
+func (s *KeySigner) SignDigestWithNonce(keyID string, digest []byte, counter uint32) ([]byte, error) {
+	if counter == 0 {
+		return s.SignDigest(keyID, digest)
+	}
+
+	key, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest should be 32 bytes, got %d", len(digest))
+	}
+
+	//counter as 32 bytes extra data, like nonce function of fc
+	extra := make([]byte, 32)
+	binary.BigEndian.PutUint32(extra[28:], counter)
+
+	return signRFC6979(key.Serialize(), digest, extra), nil
+}
+
+//signRFC6979 low-S signature of digest by priv with RFC6979 nonce of extra data, result is 64 bytes r || s
+func signRFC6979(priv, digest, extra []byte) []byte {
+	var d, e btcecv2.ModNScalar
+	d.SetByteSlice(priv)
+	e.SetByteSlice(digest)
+
+	for iteration := uint32(0); ; iteration++ {
+		k := btcecv2.NonceRFC6979(priv, digest, extra, nil, iteration)
+
+		var point btcecv2.JacobianPoint
+		btcecv2.ScalarBaseMultNonConst(k, &point)
+		point.ToAffine()
+
+		var r btcecv2.ModNScalar
+		r.SetByteSlice(point.X.Bytes()[:])
+		if r.IsZero() {
+			continue
+		}
+
+		//s = k^-1 * (e + r * d)
+		var kInv, sig btcecv2.ModNScalar
+		kInv.InverseValNonConst(k)
+		sig.Mul2(&r, &d).Add(&e).Mul(&kInv)
+		if sig.IsZero() {
+			continue
+		}
+
+		if sig.IsOverHalfOrder() {
+			sig.Negate()
+		}
+
+		rBytes, sBytes := r.Bytes(), sig.Bytes()
+		return append(rBytes[:], sBytes[:]...)
+	}
+}
+
+//MockSigner Signer for tests, records key ids and digests of signing, fails after Fail is called
+type MockSigner struct {
+	Signer Signer
+
+	err     error
+	keyIDs  []string
+	digests [][]byte
+}
+
+//NewMockSigner mock of signer
+func NewMockSigner(signer Signer) *MockSigner {
+	return &MockSigner{Signer: signer}
+}
+
+//Fail make later calls fail with message
+func (m *MockSigner) Fail(message string) {
+	m.err = errors.New(message)
+}
+
+//SignCount times of signing
+func (m *MockSigner) SignCount() int {
+	return len(m.digests)
+}
+
+//SignedKeyID key id of the i-th signing
+func (m *MockSigner) SignedKeyID(i int) string {
+	if i < 0 || i >= len(m.keyIDs) {
+		return ""
+	}
+
+	return m.keyIDs[i]
+}
+
+//SignedDigest hex digest of the i-th signing
+func (m *MockSigner) SignedDigest(i int) string {
+	if i < 0 || i >= len(m.digests) {
+		return ""
+	}
+
+	return hex.EncodeToString(m.digests[i])
+}
+
+//record key id and digest of signing, the error of Fail if set
+func (m *MockSigner) record(keyID string, digest []byte) error {
+	m.keyIDs = append(m.keyIDs, keyID)
+	m.digests = append(m.digests, append([]byte{}, digest...))
+
+	return m.err
+}
+
+//PublicKey public key of the wrapped signer
+func (m *MockSigner) PublicKey(keyID string) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.Signer.PublicKey(keyID)
+}
+
+//SignDigest record digest then sign with the wrapped signer
+func (m *MockSigner) SignDigest(keyID string, digest []byte) ([]byte, error) {
+	if err := m.record(keyID, digest); err != nil {
+		return nil, err
+	}
+
+	return m.Signer.SignDigest(keyID, digest)
+}
+
+//SignDigestWithNonce record digest then sign with the wrapped signer, which must be a CanonicalSigner
+func (m *MockSigner) SignDigestWithNonce(keyID string, digest []byte, counter uint32) ([]byte, error) {
+	if err := m.record(keyID, digest); err != nil {
+		return nil, err
+	}
+
+	signer, ok := m.Signer.(CanonicalSigner)
+	if !ok {
+		return nil, errors.New("signer does not support signing with nonce")
+	}
+
+	return signer.SignDigestWithNonce(keyID, digest, counter)
+}
+
+//parseSignerSignature r and s of signer result, s is normalized to the lower half of the order
+func parseSignerSignature(sig []byte) (*btcec.Signature, error) {
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("signature should be 64 bytes r || s, got %d", len(sig))
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+
+	order := btcec.S256().N
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(order) >= 0 || s.Cmp(order) >= 0 {
+		return nil, errors.New("signature is out of range")
+	}
+
+	if s.Cmp(new(big.Int).Rsh(order, 1)) > 0 {
+		s.Sub(order, s)
+	}
+
+	return &btcec.Signature{R: r, S: s}, nil
+}
+
+//DERSignature convert signer result to low-S DER signature
+func DERSignature(sig []byte) ([]byte, error) {
+	signature, err := parseSignerSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature.Serialize(), nil
+}
+
+//CompactSignature convert signer result to 65 bytes compact signature of compressed pubKey:
+//header(27 + 4 + recovery id) || r || s
+func CompactSignature(pubKey, digest, sig []byte) ([]byte, error) {
+	signature, err := parseSignerSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	compact := make([]byte, 65)
+	r, s := signature.R.Bytes(), signature.S.Bytes()
+	copy(compact[33-len(r):33], r)
+	copy(compact[65-len(s):], s)
+
+	for recID := byte(0); recID < 4; recID++ {
+		compact[0] = 27 + 4 + recID
+
+		recovered, _, err := btcec.RecoverCompact(btcec.S256(), compact, digest)
+		if err != nil {
+			continue
+		}
+
+		if bytes.Equal(recovered.SerializeCompressed(), pubKey) {
+			return compact, nil
+		}
+	}
+
+	return nil, errors.New("signature does not match public key")
+}
@@ -0,0 +1,136 @@
+package hdwallet
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	btcecv2 "github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+//SchnorrSigner Signer able to make BIP-340 signatures, needed by taproot key path
+type SchnorrSigner interface {
+	Signer
+	//SignSchnorr BIP-340 signature of 32 bytes digest by key of keyID tweaked with tweak, result is 64 bytes
+	SignSchnorr(keyID string, digest, tweak []byte) ([]byte, error)
+}
+
+//TaggedHash BIP-340 tagged hash sha256(sha256(tag) || sha256(tag) || msgs)
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+
+	return h.Sum(nil)
+}
+
+//TaprootTweak BIP-341 tweak of compressed internal key without script tree
+func TaprootTweak(pubKey []byte) ([]byte, error) {
+	if len(pubKey) != btcecv2.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("internal key should be 33 bytes compressed, got %d", len(pubKey))
+	}
+
+	return TaggedHash("TapTweak", pubKey[1:]), nil
+}
+
+//TaprootOutputKey x-only output key of compressed internal key without script tree, the witness program of P2TR
+func TaprootOutputKey(pubKey []byte) ([]byte, error) {
+	tweak, err := TaprootTweak(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	//internal key with even y
+	internal, err := schnorr.ParsePubKey(pubKey[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	var t btcecv2.ModNScalar
+	if overflow := t.SetByteSlice(tweak); overflow {
+		return nil, errors.New("taproot tweak is out of range")
+	}
+
+	var p, tG, q btcecv2.JacobianPoint
+	internal.AsJacobian(&p)
+	btcecv2.ScalarBaseMultNonConst(&t, &tG)
+	btcecv2.AddNonConst(&p, &tG, &q)
+	if (q.X.IsZero() && q.Y.IsZero()) || q.Z.IsZero() {
+		return nil, errors.New("taproot output key is infinity")
+	}
+	q.ToAffine()
+
+	return schnorr.SerializePubKey(btcecv2.NewPublicKey(&q.X, &q.Y)), nil
+}
+
+//VerifySchnorr BIP-340 signature of digest by x-only public key
+func VerifySchnorr(pubKey, digest, sig []byte) bool {
+	key, err := schnorr.ParsePubKey(pubKey)
+	if err != nil {
+		return false
+	}
+
+	signature, err := schnorr.ParseSignature(sig)
+	if err != nil {
+		return false
+	}
+
+	return signature.Verify(digest, key)
+}
+
+//SignSchnorr BIP-340 signature of digest by key of keyID tweaked with tweak, result is 64 bytes
+func (s *KeySigner) SignSchnorr(keyID string, digest, tweak []byte) ([]byte, error) {
+	key, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest should be 32 bytes, got %d", len(digest))
+	}
+
+	priv, pub := btcecv2.PrivKeyFromBytes(key.Serialize())
+
+	//BIP-341 tweaks the key whose public key has even y
+	d := priv.Key
+	if pub.SerializeCompressed()[0] == 0x03 {
+		d.Negate()
+	}
+
+	var t btcecv2.ModNScalar
+	if overflow := t.SetByteSlice(tweak); overflow {
+		return nil, errors.New("taproot tweak is out of range")
+	}
+
+	d.Add(&t)
+	if d.IsZero() {
+		return nil, errors.New("tweaked key is zero")
+	}
+
+	sig, err := schnorr.Sign(btcecv2.PrivKeyFromScalar(&d), digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return sig.Serialize(), nil
+}
+
+//SignSchnorr record digest then sign with the wrapped signer, which must be a SchnorrSigner
+func (m *MockSigner) SignSchnorr(keyID string, digest, tweak []byte) ([]byte, error) {
+	if err := m.record(keyID, digest); err != nil {
+		return nil, err
+	}
+
+	signer, ok := m.Signer.(SchnorrSigner)
+	if !ok {
+		return nil, errors.New("signer does not support schnorr signatures")
+	}
+
+	return signer.SignSchnorr(keyID, digest, tweak)
+}
@@ -0,0 +1,59 @@
+package hdwallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/mr-tron/base58"
+)
+
+//compressMagic suffix of WIF whose public key is compressed
+const compressMagic byte = 0x01
+
+//EncodeWIF WIF of private key on network of params, compressed appends the 0x01 suffix
+func EncodeWIF(key *btcec.PrivateKey, compressed bool, params *chaincfg.Params) string {
+	payload := append([]byte{params.PrivateKeyID}, key.Serialize()...)
+	if compressed {
+		payload = append(payload, compressMagic)
+	}
+
+	return base58.Encode(append(payload, CheckSum(payload)...))
+}
+
+//DecodeWIF private key and compressed flag of WIF, checksum and length are verified,
+//network byte must match params unless params is nil
+func DecodeWIF(wif string, params *chaincfg.Params) (*btcec.PrivateKey, bool, error) {
+	decoded, err := base58.Decode(wif)
+	if err != nil {
+		return nil, false, errors.New("invalid wif string")
+	}
+
+	//network byte, 32 bytes key, optional compress flag, 4 bytes checksum
+	var compressed bool
+	switch len(decoded) {
+	case 1 + btcec.PrivKeyBytesLen + 4:
+	case 1 + btcec.PrivKeyBytesLen + 1 + 4:
+		if decoded[1+btcec.PrivKeyBytesLen] != compressMagic {
+			return nil, false, errors.New("invalid compress flag of wif")
+		}
+		compressed = true
+	default:
+		return nil, false, fmt.Errorf("invalid wif length %d", len(decoded))
+	}
+
+	payload := decoded[:len(decoded)-4]
+	if !bytes.Equal(CheckSum(payload), decoded[len(decoded)-4:]) {
+		return nil, false, errors.New("checksum mismatch of wif")
+	}
+
+	if params != nil && payload[0] != params.PrivateKeyID {
+		return nil, false, fmt.Errorf("wif is not for network %s", params.Name)
+	}
+
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), payload[1:1+btcec.PrivKeyBytesLen])
+
+	return key, compressed, nil
+}
@@ -2,11 +2,16 @@ package vex
 
 import (
 	"bytes"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 	"github.com/tsfdsong/eos-go"
 	"github.com/tsfdsong/eos-go/ecc"
 	"github.com/tsfdsong/eos-go/system"
@@ -30,31 +35,116 @@ func enc(v interface{}) ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
-func getRawTxData(tx *eos.Transaction, chainID []byte, wifPriKey string) (string, error) {
-	bytes, _ := json.Marshal(tx)
-	fmt.Printf("get raw tx data: %v\n", string(bytes))
+//txKey signs transaction with wif private key, or with keyID of external signer
+type txKey struct {
+	wif    string
+	signer hdwallet.Signer
+	keyID  string
+}
 
-	//tx sig digest
-	sigTx := eos.NewSignedTransaction(tx)
+func wifKey(wifPriKey string) *txKey {
+	return &txKey{wif: wifPriKey}
+}
+
+//maxCanonicalTries times to sign again until external signer gives canonical signature
+const maxCanonicalTries = 32
 
+//isCanonical EOSIO only accepts signatures whose r and s are 32 bytes without high bit
+func isCanonical(compact []byte) bool {
+	return compact[1]&0x80 == 0 && !(compact[1] == 0 && compact[2]&0x80 == 0) &&
+		compact[33]&0x80 == 0 && !(compact[33] == 0 && compact[34]&0x80 == 0)
+}
+
+func sigDigest(sigTx *eos.SignedTransaction, chainID []byte) ([]byte, error) {
 	txdata, cfd, err := sigTx.PackedTransactionAndCFD()
 	if err != nil {
-		return "", fmt.Errorf("packed transaction: %v", err)
+		return nil, fmt.Errorf("packed transaction: %v", err)
 	}
 
-	sigDigest := eos.SigDigest(chainID, txdata, cfd)
+	return eos.SigDigest(chainID, txdata, cfd), nil
+}
+
+//sign signature of sigTx, when the signer gives a non-canonical signature it signs again with another
+//nonce if it is a hdwallet.CanonicalSigner, or else the expiration is moved forward unless fixedExpiration is set
+func (k *txKey) sign(sigTx *eos.SignedTransaction, chainID []byte, fixedExpiration bool) (ecc.Signature, error) {
+	if k.signer == nil {
+		digest, err := sigDigest(sigTx, chainID)
+		if err != nil {
+			return ecc.Signature{}, err
+		}
+
+		privateKey, err := ecc.NewPrivateKey(k.wif)
+		if err != nil {
+			return ecc.Signature{}, fmt.Errorf("NewPrivateKey: %s", err)
+		}
+
+		sig, err := privateKey.Sign(digest)
+		if err != nil {
+			return ecc.Signature{}, fmt.Errorf("signing through privatekey: %s", err)
+		}
 
-	//
-	privateKey, err := ecc.NewPrivateKey(wifPriKey)
+		return sig, nil
+	}
+
+	pubKey, err := k.signer.PublicKey(k.keyID)
 	if err != nil {
-		return "", fmt.Errorf("NewPrivateKey: %s", err)
+		return ecc.Signature{}, fmt.Errorf("signer public key: %v", err)
 	}
-	str := hex.EncodeToString(sigDigest)
-	fmt.Printf("hex: %v\n", str)
 
-	sig, err := privateKey.Sign(sigDigest)
+	nonceSigner, withNonce := k.signer.(hdwallet.CanonicalSigner)
+
+	for i := 0; i < maxCanonicalTries; i++ {
+		digest, err := sigDigest(sigTx, chainID)
+		if err != nil {
+			return ecc.Signature{}, err
+		}
+
+		var raw []byte
+		if withNonce {
+			raw, err = nonceSigner.SignDigestWithNonce(k.keyID, digest, uint32(i))
+		} else {
+			raw, err = k.signer.SignDigest(k.keyID, digest)
+		}
+		if err != nil {
+			return ecc.Signature{}, fmt.Errorf("signing through signer: %v", err)
+		}
+
+		compact, err := hdwallet.CompactSignature(pubKey, digest, raw)
+		if err != nil {
+			return ecc.Signature{}, err
+		}
+
+		if isCanonical(compact) {
+			return ecc.NewSignatureFromData(append([]byte{byte(ecc.CurveK1)}, compact...))
+		}
+
+		if withNonce {
+			continue
+		}
+
+		if fixedExpiration {
+			return ecc.Signature{}, errors.New("signer gives no canonical signature and expiration can not be changed, signer should be a CanonicalSigner")
+		}
+
+		//deterministic signer gives the same signature again, sign another digest
+		sigTx.Expiration = eos.JSONTime{Time: sigTx.Expiration.Add(time.Second)}
+	}
+
+	return ecc.Signature{}, errors.New("signer gives no canonical signature")
+}
+
+func getRawTxData(tx *eos.Transaction, info *txInfo, key *txKey) (string, error) {
+	if !info.Expiration.IsZero() {
+		tx.Expiration = eos.JSONTime{Time: info.Expiration}
+	}
+	tx.DelaySec = eos.Varuint32(info.DelaySec)
+
+	//tx sig digest
+	sigTx := eos.NewSignedTransaction(tx)
+
+	sig, err := key.sign(sigTx, info.ChainID, !info.Expiration.IsZero())
 	if err != nil {
-		return "", fmt.Errorf("signing through privatekey: %s", err)
+		return "", err
 	}
 	sigTx.Signatures = append(sigTx.Signatures, sig)
 
@@ -73,24 +163,80 @@ func getRawTxData(tx *eos.Transaction, chainID []byte, wifPriKey string) (string
 	return txres, nil
 }
 
-func transferAmount(info *eos.InfoResp, in *TransferInfo, wifPriKey, perm string) (string, error) {
+//symbolCodePattern symbol code of EOSIO asset
+var symbolCodePattern = regexp.MustCompile(`^[A-Z]{1,7}$`)
 
-	txOpts := &eos.TxOptions{
-		ChainID:     info.ChainID,
-		HeadBlockID: info.HeadBlockID,
+//parseSymbol symbol of "precision,CODE", e.g. "4,VEX"
+func parseSymbol(symbol string) (eos.Symbol, error) {
+	parts := strings.Split(symbol, ",")
+	if len(parts) != 2 {
+		return eos.Symbol{}, fmt.Errorf("symbol %q should be precision,CODE", symbol)
 	}
 
-	quantity, err := eos.NewFixedSymbolAssetFromString(VEXSymbol, in.Quantity)
+	precision, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil || precision > 18 {
+		return eos.Symbol{}, fmt.Errorf("invalid precision of symbol %q", symbol)
+	}
+
+	if !symbolCodePattern.MatchString(parts[1]) {
+		return eos.Symbol{}, fmt.Errorf("invalid code of symbol %q", symbol)
+	}
+
+	return eos.Symbol{Precision: uint8(precision), Symbol: parts[1]}, nil
+}
+
+//newSymbolAsset asset of quantity, which must have the precision and code of symbol
+func newSymbolAsset(quantity string, symbol eos.Symbol) (eos.Asset, error) {
+	asset, err := eos.NewAssetFromString(quantity)
 	if err != nil {
-		return "", fmt.Errorf("transferAmount new asset %v", err)
+		return eos.Asset{}, fmt.Errorf("new asset %v", err)
+	}
+
+	if asset.Symbol.Symbol != symbol.Symbol || asset.Symbol.Precision != symbol.Precision {
+		return eos.Asset{}, fmt.Errorf("quantity %s does not match symbol %d,%s", quantity, symbol.Precision, symbol.Symbol)
+	}
+
+	if asset.Amount < 0 {
+		return eos.Asset{}, fmt.Errorf("quantity %s should not be negative", quantity)
+	}
+
+	return asset, nil
+}
+
+//newTransferAction transfer action of token contract, quantity must have the precision and code of Symbol,
+//VEX quantity without Symbol is padded to the precision of VEX
+func newTransferAction(in *TransferInfo, perm string) (*eos.Action, error) {
+	contract := in.Contract
+	if contract == "" {
+		contract = VEXTokenContract
+	}
+
+	var quantity eos.Asset
+	if in.Symbol == "" {
+		var err error
+		if quantity, err = eos.NewFixedSymbolAssetFromString(VEXSymbol, in.Quantity); err != nil {
+			return nil, fmt.Errorf("new asset %v", err)
+		}
+	} else {
+		symbol, err := parseSymbol(in.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		if quantity, err = newSymbolAsset(in.Quantity, symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	if quantity.Amount <= 0 {
+		return nil, fmt.Errorf("quantity %s should be positive", in.Quantity)
 	}
 
 	from := eos.AccountName(in.From)
 	to := eos.AccountName(in.To)
-	memo := in.Memo
 
-	act := &eos.Action{
-		Account: token.AN("vex.token"),
+	return &eos.Action{
+		Account: token.AN(contract),
 		Name:    token.ActN("transfer"),
 		Authorization: []eos.PermissionLevel{
 			{Actor: from, Permission: token.PN(perm)},
@@ -99,13 +245,39 @@ func transferAmount(info *eos.InfoResp, in *TransferInfo, wifPriKey, perm string
 			From:     from,
 			To:       to,
 			Quantity: quantity,
-			Memo:     memo,
+			Memo:     in.Memo,
 		}),
+	}, nil
+}
+
+func transferAmount(info *txInfo, in *TransferInfo, key *txKey, perm string) (string, error) {
+	return batchTransfer(info, []TransferInfo{*in}, key, perm)
+}
+
+//batchTransfer transfers of any tokens in one transaction
+func batchTransfer(info *txInfo, ins []TransferInfo, key *txKey, perm string) (string, error) {
+	txOpts := &eos.TxOptions{
+		ChainID:     info.ChainID,
+		HeadBlockID: info.HeadBlockID,
+	}
+
+	if len(ins) == 0 {
+		return "", errors.New("transferAmount no transfer")
+	}
+
+	actList := make([]*eos.Action, 0, len(ins))
+	for i := range ins {
+		act, err := newTransferAction(&ins[i], perm)
+		if err != nil {
+			return "", fmt.Errorf("transferAmount %v", err)
+		}
+
+		actList = append(actList, act)
 	}
 
-	tx := eos.NewTransaction([]*eos.Action{act}, txOpts)
+	tx := eos.NewTransaction(actList, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("transferAmount %v", err)
 	}
@@ -113,63 +285,106 @@ func transferAmount(info *eos.InfoResp, in *TransferInfo, wifPriKey, perm string
 	return data, nil
 }
 
-func newVexAccount(in *NewAccountInfo, perm string) (*eos.Action, error) {
-	ownerKeys := make([]eos.KeyWeight, 0)
-	for _, onk := range in.Owner.Keys {
+//newAuthority authority with keys, accounts and waits sorted as the chain requires,
+//weights must reach threshold
+func newAuthority(in *AuthorityInfo) (eos.Authority, error) {
+	total := uint32(0)
+
+	keys := make([]eos.KeyWeight, 0, len(in.Keys))
+	for _, onk := range in.Keys {
 		pubKey, err := ecc.NewPublicKey(onk.PublicKey)
 		if err != nil {
-			fmt.Printf("NewPublicKey: %v\n", err)
-			return nil, fmt.Errorf("new public key: %v", err)
+			return eos.Authority{}, fmt.Errorf("new public key: %v", err)
 		}
 
-		tmp := eos.KeyWeight{
+		keys = append(keys, eos.KeyWeight{
 			PublicKey: pubKey,
 			Weight:    onk.Weight,
+		})
+		total += uint32(onk.Weight)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].PublicKey.Curve != keys[j].PublicKey.Curve {
+			return keys[i].PublicKey.Curve < keys[j].PublicKey.Curve
 		}
 
-		ownerKeys = append(ownerKeys, tmp)
+		return bytes.Compare(keys[i].PublicKey.Content, keys[j].PublicKey.Content) < 0
+	})
+
+	for i := 1; i < len(keys); i++ {
+		if keys[i].PublicKey.String() == keys[i-1].PublicKey.String() {
+			return eos.Authority{}, fmt.Errorf("duplicate key %s", keys[i].PublicKey)
+		}
 	}
 
-	activeKeys := make([]eos.KeyWeight, 0)
-	for _, onk := range in.Active.Keys {
-		pubKey, err := ecc.NewPublicKey(onk.PublicKey)
-		if err != nil {
-			fmt.Printf("NewPublicKey: %v\n", err)
-			return nil, fmt.Errorf("new public key: %v", err)
+	accounts := make([]eos.PermissionLevelWeight, 0, len(in.Accounts))
+	for _, onp := range in.Accounts {
+		if _, err := eos.StringToName(string(onp.Permission.Actor)); err != nil {
+			return eos.Authority{}, fmt.Errorf("invalid actor %s: %v", onp.Permission.Actor, err)
 		}
 
-		tmp := eos.KeyWeight{
-			PublicKey: pubKey,
-			Weight:    onk.Weight,
+		if _, err := eos.StringToName(string(onp.Permission.Permission)); err != nil {
+			return eos.Authority{}, fmt.Errorf("invalid permission %s: %v", onp.Permission.Permission, err)
 		}
 
-		activeKeys = append(activeKeys, tmp)
+		accounts = append(accounts, onp)
+		total += uint32(onp.Weight)
 	}
 
-	ownerAccounts := make([]eos.PermissionLevelWeight, 0)
-	for _, onp := range in.Owner.Accounts {
-		tmp := eos.PermissionLevelWeight{
-			Permission: eos.PermissionLevel{
-				Actor:      eos.AccountName(onp.Permission.Actor),
-				Permission: eos.PermissionName(onp.Permission.Permission),
-			},
-			Weight: onp.Weight,
+	sort.Slice(accounts, func(i, j int) bool {
+		ai, _ := eos.StringToName(string(accounts[i].Permission.Actor))
+		aj, _ := eos.StringToName(string(accounts[j].Permission.Actor))
+		if ai != aj {
+			return ai < aj
 		}
 
-		ownerAccounts = append(ownerAccounts, tmp)
+		pi, _ := eos.StringToName(string(accounts[i].Permission.Permission))
+		pj, _ := eos.StringToName(string(accounts[j].Permission.Permission))
+		return pi < pj
+	})
+
+	for i := 1; i < len(accounts); i++ {
+		if accounts[i].Permission == accounts[i-1].Permission {
+			return eos.Authority{}, fmt.Errorf("duplicate account %s@%s", accounts[i].Permission.Actor, accounts[i].Permission.Permission)
+		}
 	}
 
-	activeAccounts := make([]eos.PermissionLevelWeight, 0)
-	for _, onp := range in.Active.Accounts {
-		tmp := eos.PermissionLevelWeight{
-			Permission: eos.PermissionLevel{
-				Actor:      eos.AccountName(onp.Permission.Actor),
-				Permission: eos.PermissionName(onp.Permission.Permission),
-			},
-			Weight: onp.Weight,
+	waits := make([]eos.WaitWeight, 0, len(in.Waits))
+	for _, w := range in.Waits {
+		if w.WaitSec == 0 {
+			return eos.Authority{}, errors.New("wait should be positive")
 		}
 
-		activeAccounts = append(activeAccounts, tmp)
+		waits = append(waits, w)
+		total += uint32(w.Weight)
+	}
+
+	sort.SliceStable(waits, func(i, j int) bool {
+		return waits[i].WaitSec < waits[j].WaitSec
+	})
+
+	if in.Threshold == 0 || total < in.Threshold {
+		return eos.Authority{}, fmt.Errorf("weights %d can not reach threshold %d", total, in.Threshold)
+	}
+
+	return eos.Authority{
+		Threshold: in.Threshold,
+		Keys:      keys,
+		Accounts:  accounts,
+		Waits:     waits,
+	}, nil
+}
+
+func newVexAccount(in *NewAccountInfo, perm string) (*eos.Action, error) {
+	owner, err := newAuthority(&in.Owner)
+	if err != nil {
+		return nil, fmt.Errorf("owner: %v", err)
+	}
+
+	active, err := newAuthority(&in.Active)
+	if err != nil {
+		return nil, fmt.Errorf("active: %v", err)
 	}
 
 	return &eos.Action{
@@ -181,21 +396,13 @@ func newVexAccount(in *NewAccountInfo, perm string) (*eos.Action, error) {
 		ActionData: eos.NewActionData(system.NewAccount{
 			Creator: eos.AccountName(in.Creator),
 			Name:    eos.AccountName(in.Name),
-			Owner: eos.Authority{
-				Threshold: in.Owner.Threshold,
-				Keys:      ownerKeys,
-				Accounts:  ownerAccounts,
-			},
-			Active: eos.Authority{
-				Threshold: in.Active.Threshold,
-				Keys:      activeKeys,
-				Accounts:  activeAccounts,
-			},
+			Owner:   owner,
+			Active:  active,
 		}),
 	}, nil
 }
 
-func createAccount(info *eos.InfoResp, account *CreateAccountInfo, wifPriKey, perm string) (string, error) {
+func createAccount(info *txInfo, account *CreateAccountInfo, key *txKey, perm string) (string, error) {
 	txOpts := &eos.TxOptions{
 		ChainID:     info.ChainID,
 		HeadBlockID: info.HeadBlockID,
@@ -250,7 +457,7 @@ func createAccount(info *eos.InfoResp, account *CreateAccountInfo, wifPriKey, pe
 
 	tx := eos.NewTransaction([]*eos.Action{actAccount, actBuyRAM, actBW}, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("createAccount %v", err)
 	}
@@ -258,7 +465,7 @@ func createAccount(info *eos.InfoResp, account *CreateAccountInfo, wifPriKey, pe
 	return data, nil
 }
 
-func sellRAM(info *eos.InfoResp, in *SellRAMInfo, wifPriKey, perm string) (string, error) {
+func sellRAM(info *txInfo, in *SellRAMInfo, key *txKey, perm string) (string, error) {
 	txOpts := &eos.TxOptions{
 		ChainID:     info.ChainID,
 		HeadBlockID: info.HeadBlockID,
@@ -283,7 +490,7 @@ func sellRAM(info *eos.InfoResp, in *SellRAMInfo, wifPriKey, perm string) (strin
 
 	tx := eos.NewTransaction([]*eos.Action{actSellRAM}, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("sellRAM %v", err)
 	}
@@ -291,7 +498,7 @@ func sellRAM(info *eos.InfoResp, in *SellRAMInfo, wifPriKey, perm string) (strin
 	return data, nil
 }
 
-func buyRAM(info *eos.InfoResp, in *BuyRAMInfo, wifPriKey, perm string) (string, error) {
+func buyRAM(info *txInfo, in *BuyRAMInfo, key *txKey, perm string) (string, error) {
 	txOpts := &eos.TxOptions{
 		ChainID:     info.ChainID,
 		HeadBlockID: info.HeadBlockID,
@@ -317,7 +524,7 @@ func buyRAM(info *eos.InfoResp, in *BuyRAMInfo, wifPriKey, perm string) (string,
 
 	tx := eos.NewTransaction([]*eos.Action{actBuyRAM}, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("buyRAM %v", err)
 	}
@@ -325,7 +532,7 @@ func buyRAM(info *eos.InfoResp, in *BuyRAMInfo, wifPriKey, perm string) (string,
 	return data, nil
 }
 
-func delegateBW(info *eos.InfoResp, bw *DelegateBWInfo, wifPriKey, perm string) (string, error) {
+func delegateBW(info *txInfo, bw *DelegateBWInfo, key *txKey, perm string) (string, error) {
 	txOpts := &eos.TxOptions{
 		ChainID:     info.ChainID,
 		HeadBlockID: info.HeadBlockID,
@@ -363,7 +570,7 @@ func delegateBW(info *eos.InfoResp, bw *DelegateBWInfo, wifPriKey, perm string)
 
 	tx := eos.NewTransaction([]*eos.Action{actBW}, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("delegateBW %v", err)
 	}
@@ -371,7 +578,7 @@ func delegateBW(info *eos.InfoResp, bw *DelegateBWInfo, wifPriKey, perm string)
 	return data, nil
 }
 
-func unDelegateBW(info *eos.InfoResp, bws []UnDelegateBWInfo, wifPriKey, perm string) (string, error) {
+func unDelegateBW(info *txInfo, bws []UnDelegateBWInfo, key *txKey, perm string) (string, error) {
 	txOpts := &eos.TxOptions{
 		ChainID:     info.ChainID,
 		HeadBlockID: info.HeadBlockID,
@@ -408,7 +615,7 @@ func unDelegateBW(info *eos.InfoResp, bws []UnDelegateBWInfo, wifPriKey, perm st
 
 	tx := eos.NewTransaction(actList, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("unDelegateBW %v", err)
 	}
@@ -416,7 +623,7 @@ func unDelegateBW(info *eos.InfoResp, bws []UnDelegateBWInfo, wifPriKey, perm st
 	return data, nil
 }
 
-func buyRAMBytes(info *eos.InfoResp, in *BuyRAMBytes, wifPriKey, perm string) (string, error) {
+func buyRAMBytes(info *txInfo, in *BuyRAMBytes, key *txKey, perm string) (string, error) {
 	txOpts := &eos.TxOptions{
 		ChainID:     info.ChainID,
 		HeadBlockID: info.HeadBlockID,
@@ -437,10 +644,108 @@ func buyRAMBytes(info *eos.InfoResp, in *BuyRAMBytes, wifPriKey, perm string) (s
 
 	tx := eos.NewTransaction([]*eos.Action{actBuyRAM}, txOpts)
 
-	data, err := getRawTxData(tx, info.ChainID, wifPriKey)
+	data, err := getRawTxData(tx, info, key)
 	if err != nil {
 		return "", fmt.Errorf("buyRAMBytes %v", err)
 	}
 
 	return data, nil
 }
+
+//sortProducers producers sorted by name value without duplicates, as voteproducer requires
+func sortProducers(producers []string) ([]eos.AccountName, error) {
+	if len(producers) > MaxVoteProducers {
+		return nil, fmt.Errorf("can vote for at most %d producers, got %d", MaxVoteProducers, len(producers))
+	}
+
+	values := make(map[eos.AccountName]uint64)
+	names := make([]eos.AccountName, 0, len(producers))
+	for _, producer := range producers {
+		name := eos.AccountName(producer)
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("producer %s is voted twice", producer)
+		}
+
+		value, err := eos.StringToName(producer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid producer name %s: %v", producer, err)
+		}
+
+		values[name] = value
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return values[names[i]] < values[names[j]]
+	})
+
+	return names, nil
+}
+
+func voteProducer(info *txInfo, in *VoteProducerInfo, key *txKey, perm string) (string, error) {
+	txOpts := &eos.TxOptions{
+		ChainID:     info.ChainID,
+		HeadBlockID: info.HeadBlockID,
+	}
+
+	if in.Proxy != "" && len(in.Producers) > 0 {
+		return "", errors.New("voteProducer can not vote producers and proxy at once")
+	}
+
+	producers, err := sortProducers(in.Producers)
+	if err != nil {
+		return "", fmt.Errorf("voteProducer %v", err)
+	}
+
+	voter := eos.AccountName(in.Voter)
+	actVote := &eos.Action{
+		Account: eos.AN("vexcore"),
+		Name:    eos.ActN("voteproducer"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: voter, Permission: eos.PN(perm)},
+		},
+		ActionData: eos.NewActionData(system.VoteProducer{
+			Voter:     voter,
+			Proxy:     eos.AccountName(in.Proxy),
+			Producers: producers,
+		}),
+	}
+
+	tx := eos.NewTransaction([]*eos.Action{actVote}, txOpts)
+
+	data, err := getRawTxData(tx, info, key)
+	if err != nil {
+		return "", fmt.Errorf("voteProducer %v", err)
+	}
+
+	return data, nil
+}
+
+func regProxy(info *txInfo, in *RegProxyInfo, key *txKey, perm string) (string, error) {
+	txOpts := &eos.TxOptions{
+		ChainID:     info.ChainID,
+		HeadBlockID: info.HeadBlockID,
+	}
+
+	proxy := eos.AccountName(in.Proxy)
+	actRegProxy := &eos.Action{
+		Account: eos.AN("vexcore"),
+		Name:    eos.ActN("regproxy"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: proxy, Permission: eos.PN(perm)},
+		},
+		ActionData: eos.NewActionData(system.RegProxy{
+			Proxy:   proxy,
+			IsProxy: in.IsProxy > 0,
+		}),
+	}
+
+	tx := eos.NewTransaction([]*eos.Action{actRegProxy}, txOpts)
+
+	data, err := getRawTxData(tx, info, key)
+	if err != nil {
+		return "", fmt.Errorf("regProxy %v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,117 @@
+package vex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tsfdsong/eos-go"
+)
+
+//loadABI parse abi of contract, the result of get_abi is unwrapped
+func loadABI(abiJSON json.RawMessage) (*eos.ABI, error) {
+	if len(abiJSON) == 0 {
+		return nil, errors.New("abi is required")
+	}
+
+	var resp struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(abiJSON, &resp); err == nil && len(resp.ABI) > 0 {
+		abiJSON = resp.ABI
+	}
+
+	abi, err := eos.NewABI(bytes.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("load abi: %v", err)
+	}
+
+	return abi, nil
+}
+
+//newAuthorization permission levels of action, empty permission is perm
+func newAuthorization(auths []PermissionInfo, perm string) ([]eos.PermissionLevel, error) {
+	if len(auths) == 0 {
+		return nil, errors.New("authorization is required")
+	}
+
+	levels := make([]eos.PermissionLevel, 0, len(auths))
+	for _, auth := range auths {
+		permission := auth.Permission
+		if permission == "" {
+			permission = perm
+		}
+
+		levels = append(levels, eos.PermissionLevel{
+			Actor:      eos.AN(auth.Actor),
+			Permission: eos.PN(permission),
+		})
+	}
+
+	return levels, nil
+}
+
+//newCustomAction action of any contract, data is serialized by the abi given by caller
+func newCustomAction(in *ActionInfo, perm string) (*eos.Action, error) {
+	if in.Account == "" || in.Name == "" {
+		return nil, errors.New("contract account and action name are required")
+	}
+
+	abi, err := loadABI(in.ABI)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte(in.Data)
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+
+	actionData, err := abi.EncodeAction(eos.ActN(in.Name), data)
+	if err != nil {
+		return nil, fmt.Errorf("encode action %s::%s: %v", in.Account, in.Name, err)
+	}
+
+	auths, err := newAuthorization(in.Authorization, perm)
+	if err != nil {
+		return nil, fmt.Errorf("action %s::%s: %v", in.Account, in.Name, err)
+	}
+
+	return &eos.Action{
+		Account:       eos.AN(in.Account),
+		Name:          eos.ActN(in.Name),
+		Authorization: auths,
+		ActionData:    eos.ActionData{HexData: actionData},
+	}, nil
+}
+
+func customActions(info *txInfo, acts []ActionInfo, key *txKey, perm string) (string, error) {
+	txOpts := &eos.TxOptions{
+		ChainID:     info.ChainID,
+		HeadBlockID: info.HeadBlockID,
+	}
+
+	if len(acts) == 0 {
+		return "", errors.New("customActions no action")
+	}
+
+	actList := make([]*eos.Action, 0, len(acts))
+	for i := range acts {
+		act, err := newCustomAction(&acts[i], perm)
+		if err != nil {
+			return "", fmt.Errorf("customActions %v", err)
+		}
+
+		actList = append(actList, act)
+	}
+
+	tx := eos.NewTransaction(actList, txOpts)
+
+	data, err := getRawTxData(tx, info, key)
+	if err != nil {
+		return "", fmt.Errorf("customActions %v", err)
+	}
+
+	return data, nil
+}
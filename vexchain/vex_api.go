@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 	"github.com/tsfdsong/eos-go"
 )
 
@@ -22,16 +23,97 @@ const (
 	tVEXTransferTypeTransferAmount
 	// 购买内存bytes
 	tVEXTransferTypeBuyRamBytes
+	//任意合约action, data为ActionInfo数组
+	tVEXTransferTypeAction
+	//批量转账, data为TransferInfo数组
+	tVEXTransferTypeBatchTransfer
+	//投票给节点或代理
+	tVEXTransferTypeVoteProducer
+	//注册投票代理
+	tVEXTransferTypeRegProxy
+	//领取赎回的VEX
+	tVEXTransferTypeRefund
+	//REX存入
+	tVEXTransferTypeREXDeposit
+	//REX取出
+	tVEXTransferTypeREXWithdraw
+	//购买REX
+	tVEXTransferTypeBuyREX
+	//出售REX
+	tVEXTransferTypeSellREX
+	//租用CPU
+	tVEXTransferTypeRentCPU
+	//租用NET
+	tVEXTransferTypeRentNET
+	//修改或新增权限
+	tVEXTransferTypeUpdateAuth
+	//删除权限
+	tVEXTransferTypeDeleteAuth
+	//权限关联合约action
+	tVEXTransferTypeLinkAuth
+	//取消权限关联
+	tVEXTransferTypeUnlinkAuth
+	//多签提案
+	tVEXTransferTypeMsigPropose
+	//多签批准
+	tVEXTransferTypeMsigApprove
+	//多签取消批准
+	tVEXTransferTypeMsigUnapprove
+	//多签执行
+	tVEXTransferTypeMsigExec
+	//多签撤销提案
+	tVEXTransferTypeMsigCancel
 )
 
 //VexAPI common api
 func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error) {
+	return vexAPI(cmdType, infoStr, data, wifKey(wifPriKey), perm)
+}
+
+//VexAPIWithSigner common api, transaction is signed by signer with keyID
+func VexAPIWithSigner(cmdType int, infoStr, data, keyID, perm string, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	return vexAPI(cmdType, infoStr, data, &txKey{signer: signer, keyID: keyID}, perm)
+}
+
+func vexAPI(cmdType int, infoStr, data string, key *txKey, perm string) (string, error) {
 	var info eos.InfoResp
 	err := json.Unmarshal([]byte(infoStr), &info)
 	if err != nil {
 		return "", fmt.Errorf("unmarshal info: %v", err)
 	}
 
+	return vexTx(cmdType, infoOf(&info), data, key, perm)
+}
+
+//VexOfflineAPI like VexAPI without node access, infoStr is json of OfflineTxInfo
+func VexOfflineAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error) {
+	info, err := offlineTxInfo(infoStr)
+	if err != nil {
+		return "", err
+	}
+
+	return vexTx(cmdType, info, data, wifKey(wifPriKey), perm)
+}
+
+//VexOfflineAPIWithSigner like VexOfflineAPI, transaction is signed by signer with keyID
+func VexOfflineAPIWithSigner(cmdType int, infoStr, data, keyID, perm string, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	info, err := offlineTxInfo(infoStr)
+	if err != nil {
+		return "", err
+	}
+
+	return vexTx(cmdType, info, data, &txKey{signer: signer, keyID: keyID}, perm)
+}
+
+func vexTx(cmdType int, info *txInfo, data string, key *txKey, perm string) (string, error) {
 	switch cmdType {
 	case tVEXTransferTypeUndelegatebw:
 		{
@@ -41,7 +123,7 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal UnDelegateBWInfo: %v", err)
 			}
 
-			return unDelegateBW(&info, bw, wifPriKey, perm)
+			return unDelegateBW(info, bw, key, perm)
 		}
 	case tVEXTransferTypeDelegatebw:
 		{
@@ -51,7 +133,7 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal DelegateBWInfo: %v", err)
 			}
 
-			return delegateBW(&info, &bw, wifPriKey, perm)
+			return delegateBW(info, &bw, key, perm)
 		}
 	case tVEXTransferTypeBuyRam:
 		{
@@ -61,7 +143,7 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal BuyRAMInfo: %v", err)
 			}
 
-			return buyRAM(&info, &ram, wifPriKey, perm)
+			return buyRAM(info, &ram, key, perm)
 		}
 	case tVEXTransferTypeSellRam:
 		{
@@ -71,7 +153,7 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal SellRAMInfo: %v", err)
 			}
 
-			return sellRAM(&info, &ram, wifPriKey, perm)
+			return sellRAM(info, &ram, key, perm)
 		}
 	case tVEXTransferTypeCreateAccount:
 		{
@@ -81,7 +163,7 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal CreateAccountInfo: %v", err)
 			}
 
-			return createAccount(&info, &acct, wifPriKey, perm)
+			return createAccount(info, &acct, key, perm)
 		}
 	case tVEXTransferTypeTransferAmount:
 		{
@@ -91,7 +173,7 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal TransferInfo: %v", err)
 			}
 
-			return transferAmount(&info, &trans, wifPriKey, perm)
+			return transferAmount(info, &trans, key, perm)
 		}
 	case tVEXTransferTypeBuyRamBytes:
 		{
@@ -101,7 +183,174 @@ func VexAPI(cmdType int, infoStr, data, wifPriKey, perm string) (string, error)
 				return "", fmt.Errorf("unmarshal BuyRAMBytes: %v", err)
 			}
 
-			return buyRAMBytes(&info, &ram, wifPriKey, perm)
+			return buyRAMBytes(info, &ram, key, perm)
+		}
+	case tVEXTransferTypeAction:
+		{
+			acts := make([]ActionInfo, 0)
+			err := json.Unmarshal([]byte(data), &acts)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal ActionInfo: %v", err)
+			}
+
+			return customActions(info, acts, key, perm)
+		}
+	case tVEXTransferTypeBatchTransfer:
+		{
+			trans := make([]TransferInfo, 0)
+			err := json.Unmarshal([]byte(data), &trans)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal TransferInfo: %v", err)
+			}
+
+			return batchTransfer(info, trans, key, perm)
+		}
+	case tVEXTransferTypeVoteProducer:
+		{
+			var vote VoteProducerInfo
+			err := json.Unmarshal([]byte(data), &vote)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal VoteProducerInfo: %v", err)
+			}
+
+			return voteProducer(info, &vote, key, perm)
+		}
+	case tVEXTransferTypeRegProxy:
+		{
+			var proxy RegProxyInfo
+			err := json.Unmarshal([]byte(data), &proxy)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal RegProxyInfo: %v", err)
+			}
+
+			return regProxy(info, &proxy, key, perm)
+		}
+	case tVEXTransferTypeRefund:
+		{
+			var in RefundInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal RefundInfo: %v", err)
+			}
+
+			return refund(info, &in, key, perm)
+		}
+	case tVEXTransferTypeREXDeposit, tVEXTransferTypeREXWithdraw, tVEXTransferTypeBuyREX, tVEXTransferTypeSellREX:
+		{
+			var in REXInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal REXInfo: %v", err)
+			}
+
+			switch cmdType {
+			case tVEXTransferTypeREXDeposit:
+				return rexFund(info, "deposit", &in, key, perm)
+			case tVEXTransferTypeREXWithdraw:
+				return rexFund(info, "withdraw", &in, key, perm)
+			case tVEXTransferTypeBuyREX:
+				return buyREX(info, &in, key, perm)
+			default:
+				return sellREX(info, &in, key, perm)
+			}
+		}
+	case tVEXTransferTypeRentCPU, tVEXTransferTypeRentNET:
+		{
+			var in RentInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal RentInfo: %v", err)
+			}
+
+			if cmdType == tVEXTransferTypeRentCPU {
+				return rentResource(info, "rentcpu", &in, key, perm)
+			}
+
+			return rentResource(info, "rentnet", &in, key, perm)
+		}
+	case tVEXTransferTypeUpdateAuth:
+		{
+			var in UpdateAuthInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal UpdateAuthInfo: %v", err)
+			}
+
+			return updateAuth(info, &in, key, perm)
+		}
+	case tVEXTransferTypeDeleteAuth:
+		{
+			var in DeleteAuthInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal DeleteAuthInfo: %v", err)
+			}
+
+			return deleteAuth(info, &in, key, perm)
+		}
+	case tVEXTransferTypeLinkAuth:
+		{
+			var in LinkAuthInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal LinkAuthInfo: %v", err)
+			}
+
+			return linkAuth(info, &in, key, perm)
+		}
+	case tVEXTransferTypeUnlinkAuth:
+		{
+			var in UnlinkAuthInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal UnlinkAuthInfo: %v", err)
+			}
+
+			return unlinkAuth(info, &in, key, perm)
+		}
+	case tVEXTransferTypeMsigPropose:
+		{
+			var in ProposeInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal ProposeInfo: %v", err)
+			}
+
+			return propose(info, &in, key, perm)
+		}
+	case tVEXTransferTypeMsigApprove, tVEXTransferTypeMsigUnapprove:
+		{
+			var in ApproveInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal ApproveInfo: %v", err)
+			}
+
+			if cmdType == tVEXTransferTypeMsigApprove {
+				return approve(info, "approve", &in, key, perm)
+			}
+
+			return approve(info, "unapprove", &in, key, perm)
+		}
+	case tVEXTransferTypeMsigExec:
+		{
+			var in ExecInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal ExecInfo: %v", err)
+			}
+
+			return execProposal(info, &in, key, perm)
+		}
+	case tVEXTransferTypeMsigCancel:
+		{
+			var in CancelInfo
+			err := json.Unmarshal([]byte(data), &in)
+			if err != nil {
+				return "", fmt.Errorf("unmarshal CancelInfo: %v", err)
+			}
+
+			return cancelProposal(info, &in, key, perm)
 		}
 	}
 
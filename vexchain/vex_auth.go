@@ -0,0 +1,95 @@
+package vex
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tsfdsong/eos-go"
+	"github.com/tsfdsong/eos-go/system"
+)
+
+//updateAuth rotate keys of owner or active, or add custom permission under Parent
+func updateAuth(info *txInfo, in *UpdateAuthInfo, key *txKey, perm string) (string, error) {
+	if in.Permission == "" {
+		return "", errors.New("updateauth permission is required")
+	}
+
+	parent := in.Parent
+	if in.Permission == "owner" {
+		if parent != "" {
+			return "", errors.New("updateauth owner has no parent")
+		}
+	} else if parent == "" {
+		return "", fmt.Errorf("updateauth parent of %s is required", in.Permission)
+	}
+
+	if parent == in.Permission {
+		return "", errors.New("updateauth permission can not be parent of itself")
+	}
+
+	auth, err := newAuthority(&in.Auth)
+	if err != nil {
+		return "", fmt.Errorf("updateauth %v", err)
+	}
+
+	account := eos.AccountName(in.Account)
+
+	return vexcoreTx(info, "updateauth", account, system.UpdateAuth{
+		Account:    account,
+		Permission: eos.PN(in.Permission),
+		Parent:     eos.PN(parent),
+		Auth:       auth,
+	}, key, perm)
+}
+
+//deleteAuth delete custom permission, owner and active can not be deleted
+func deleteAuth(info *txInfo, in *DeleteAuthInfo, key *txKey, perm string) (string, error) {
+	switch in.Permission {
+	case "":
+		return "", errors.New("deleteauth permission is required")
+	case "owner", "active":
+		return "", fmt.Errorf("deleteauth %s can not be deleted", in.Permission)
+	}
+
+	account := eos.AccountName(in.Account)
+
+	return vexcoreTx(info, "deleteauth", account, system.DeleteAuth{
+		Account:    account,
+		Permission: eos.PN(in.Permission),
+	}, key, perm)
+}
+
+//linkAuth let Requirement authorize action Type of contract Code
+func linkAuth(info *txInfo, in *LinkAuthInfo, key *txKey, perm string) (string, error) {
+	if in.Code == "" || in.Requirement == "" {
+		return "", errors.New("linkauth code and requirement are required")
+	}
+
+	if in.Requirement == "owner" || in.Requirement == "active" {
+		return "", fmt.Errorf("linkauth can not link to %s", in.Requirement)
+	}
+
+	account := eos.AccountName(in.Account)
+
+	return vexcoreTx(info, "linkauth", account, system.LinkAuth{
+		Account:     account,
+		Code:        eos.AccountName(in.Code),
+		Type:        eos.ActionName(in.Type),
+		Requirement: eos.PN(in.Requirement),
+	}, key, perm)
+}
+
+//unlinkAuth action Type of contract Code falls back to active
+func unlinkAuth(info *txInfo, in *UnlinkAuthInfo, key *txKey, perm string) (string, error) {
+	if in.Code == "" {
+		return "", errors.New("unlinkauth code is required")
+	}
+
+	account := eos.AccountName(in.Account)
+
+	return vexcoreTx(info, "unlinkauth", account, system.UnlinkAuth{
+		Account: account,
+		Code:    eos.AccountName(in.Code),
+		Type:    eos.ActionName(in.Type),
+	}, key, perm)
+}
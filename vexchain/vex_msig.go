@@ -0,0 +1,197 @@
+package vex
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
+	"github.com/tsfdsong/eos-go"
+)
+
+//defaultProposalHours hours before proposed transaction expires
+const defaultProposalHours = 24
+
+//proposalNamePattern name of proposal
+var proposalNamePattern = regexp.MustCompile(`^[a-z1-5.]{1,12}$`)
+
+//msigTx transaction of a single msig contract action
+func msigTx(info *txInfo, name string, auth eos.PermissionLevel, actionData interface{}, key *txKey) (string, error) {
+	return contractTx(info, VEXMsigContract, name, auth, actionData, key)
+}
+
+//checkProposal proposer and proposal name are required
+func checkProposal(name, proposer, proposalName string) error {
+	if proposer == "" || proposalName == "" {
+		return fmt.Errorf("%s proposer and proposal name are required", name)
+	}
+
+	if !proposalNamePattern.MatchString(proposalName) {
+		return fmt.Errorf("%s invalid proposal name %s", name, proposalName)
+	}
+
+	return nil
+}
+
+//propose store transaction of actions in msig contract until requested permissions approve it
+func propose(info *txInfo, in *ProposeInfo, key *txKey, perm string) (string, error) {
+	if err := checkProposal("propose", in.Proposer, in.ProposalName); err != nil {
+		return "", err
+	}
+
+	requested, err := newAuthorization(in.Requested, "active")
+	if err != nil {
+		return "", fmt.Errorf("propose requested %v", err)
+	}
+
+	if len(in.Actions) == 0 {
+		return "", errors.New("propose no action")
+	}
+
+	acts := make([]*eos.Action, 0, len(in.Actions))
+	for i := range in.Actions {
+		act, err := newCustomAction(&in.Actions[i], "active")
+		if err != nil {
+			return "", fmt.Errorf("propose %v", err)
+		}
+
+		acts = append(acts, act)
+	}
+
+	hours := in.ExpireHours
+	if hours == 0 {
+		hours = defaultProposalHours
+	}
+
+	//offline proposal counts from the explicit expiration so both sides build the same transaction
+	start := time.Now().UTC()
+	if !info.Expiration.IsZero() {
+		start = info.Expiration
+	}
+
+	//proposed transaction refers to no block, like cleos multisig propose
+	trx := &eos.Transaction{
+		TransactionHeader: eos.TransactionHeader{
+			Expiration: eos.JSONTime{Time: start.Add(time.Duration(hours) * time.Hour).Truncate(time.Second)},
+		},
+		Actions: acts,
+	}
+
+	proposer := eos.AccountName(in.Proposer)
+
+	return msigTx(info, "propose", eos.PermissionLevel{Actor: proposer, Permission: eos.PN(perm)}, msigPropose{
+		Proposer:     proposer,
+		ProposalName: eos.Name(in.ProposalName),
+		Requested:    requested,
+		Transaction:  trx,
+	}, key)
+}
+
+//approve approve or unapprove proposal with Level, Level permission is perm by default
+func approve(info *txInfo, name string, in *ApproveInfo, key *txKey, perm string) (string, error) {
+	if err := checkProposal(name, in.Proposer, in.ProposalName); err != nil {
+		return "", err
+	}
+
+	levels, err := newAuthorization([]PermissionInfo{in.Level}, perm)
+	if err != nil {
+		return "", fmt.Errorf("%s level %v", name, err)
+	}
+
+	return msigTx(info, name, levels[0], msigApprove{
+		Proposer:     eos.AccountName(in.Proposer),
+		ProposalName: eos.Name(in.ProposalName),
+		Level:        levels[0],
+	}, key)
+}
+
+//execProposal execute proposal once it has enough approvals
+func execProposal(info *txInfo, in *ExecInfo, key *txKey, perm string) (string, error) {
+	if err := checkProposal("exec", in.Proposer, in.ProposalName); err != nil {
+		return "", err
+	}
+
+	executer := eos.AccountName(in.Executer)
+
+	return msigTx(info, "exec", eos.PermissionLevel{Actor: executer, Permission: eos.PN(perm)}, msigExec{
+		Proposer:     eos.AccountName(in.Proposer),
+		ProposalName: eos.Name(in.ProposalName),
+		Executer:     executer,
+	}, key)
+}
+
+//cancelProposal cancel proposal by proposer, or by anyone after it expired
+func cancelProposal(info *txInfo, in *CancelInfo, key *txKey, perm string) (string, error) {
+	if err := checkProposal("cancel", in.Proposer, in.ProposalName); err != nil {
+		return "", err
+	}
+
+	canceler := eos.AccountName(in.Canceler)
+
+	return msigTx(info, "cancel", eos.PermissionLevel{Actor: canceler, Permission: eos.PN(perm)}, msigCancel{
+		Proposer:     eos.AccountName(in.Proposer),
+		ProposalName: eos.Name(in.ProposalName),
+		Canceler:     canceler,
+	}, key)
+}
+
+//addSignature sign packed transaction again with key, the transaction itself is left unchanged
+func addSignature(packedTx, chainID string, key *txKey) (string, error) {
+	var packed eos.PackedTransaction
+	if err := json.Unmarshal([]byte(packedTx), &packed); err != nil {
+		return "", fmt.Errorf("unmarshal packed transaction: %v", err)
+	}
+
+	id, err := hex.DecodeString(chainID)
+	if err != nil || len(id) != 32 {
+		return "", fmt.Errorf("invalid chain id %s", chainID)
+	}
+
+	sigTx, err := packed.Unpack()
+	if err != nil {
+		return "", fmt.Errorf("unpack transaction: %v", err)
+	}
+
+	//other signatures are over the original expiration
+	sig, err := key.sign(sigTx, id, true)
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range sigTx.Signatures {
+		if s.String() == sig.String() {
+			return "", errors.New("transaction is already signed by this key")
+		}
+	}
+	sigTx.Signatures = append(sigTx.Signatures, sig)
+
+	repacked, err := sigTx.Pack(packed.Compression)
+	if err != nil {
+		return "", fmt.Errorf("pack: %v", err)
+	}
+
+	txBytes, err := enc(repacked)
+	if err != nil {
+		return "", fmt.Errorf("encode transaction: %s", err)
+	}
+
+	return string(txBytes), nil
+}
+
+//AddVexSignature add signature of wif key to packed transaction of VexAPI, for accounts of multiple keys
+func AddVexSignature(packedTx, chainID, wifPriKey string) (string, error) {
+	return addSignature(packedTx, chainID, wifKey(wifPriKey))
+}
+
+//AddVexSignatureWithSigner like AddVexSignature, signed by signer with keyID, signer should be a
+//hdwallet.CanonicalSigner as the transaction can not change to get a canonical signature
+func AddVexSignatureWithSigner(packedTx, chainID, keyID string, signer hdwallet.Signer) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("signer is nil")
+	}
+
+	return addSignature(packedTx, chainID, &txKey{signer: signer, keyID: keyID})
+}
@@ -0,0 +1,86 @@
+package vex
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tsfdsong/eos-go"
+)
+
+//expirationFormat time format of transaction expiration
+const expirationFormat = "2006-01-02T15:04:05"
+
+//txInfo chain and reference block of transaction, zero Expiration is set by eos.NewTransaction
+type txInfo struct {
+	ChainID     eos.Checksum256
+	HeadBlockID eos.Checksum256
+	Expiration  time.Time
+	DelaySec    uint32
+}
+
+//infoOf transaction info of get_info result
+func infoOf(info *eos.InfoResp) *txInfo {
+	return &txInfo{
+		ChainID:     info.ChainID,
+		HeadBlockID: info.HeadBlockID,
+	}
+}
+
+//refBlockID block id giving ref_block_num and ref_block_prefix when only they are known
+func refBlockID(num, prefix uint32) eos.Checksum256 {
+	id := make([]byte, 32)
+	binary.BigEndian.PutUint32(id[0:4], num)
+	binary.LittleEndian.PutUint32(id[8:12], prefix)
+
+	return id
+}
+
+//offlineTxInfo parse json of OfflineTxInfo
+func offlineTxInfo(infoStr string) (*txInfo, error) {
+	var in OfflineTxInfo
+	if err := json.Unmarshal([]byte(infoStr), &in); err != nil {
+		return nil, fmt.Errorf("unmarshal OfflineTxInfo: %v", err)
+	}
+
+	chainID, err := hex.DecodeString(in.ChainID)
+	if err != nil || len(chainID) != 32 {
+		return nil, fmt.Errorf("invalid chain id %s", in.ChainID)
+	}
+
+	info := &txInfo{
+		ChainID:  chainID,
+		DelaySec: in.DelaySec,
+	}
+
+	switch {
+	case in.RefBlockID != "":
+		blockID, err := hex.DecodeString(in.RefBlockID)
+		if err != nil || len(blockID) != 32 {
+			return nil, fmt.Errorf("invalid ref block id %s", in.RefBlockID)
+		}
+
+		//ref_block_num of transaction keeps the low 16 bits of block number
+		if in.RefBlockNum != 0 && uint16(binary.BigEndian.Uint32(blockID[0:4])) != uint16(in.RefBlockNum) {
+			return nil, fmt.Errorf("ref block id %s is not block %d", in.RefBlockID, in.RefBlockNum)
+		}
+
+		info.HeadBlockID = blockID
+	case in.RefBlockPrefix != 0:
+		info.HeadBlockID = refBlockID(in.RefBlockNum, in.RefBlockPrefix)
+	default:
+		return nil, errors.New("ref block id, or ref block num and prefix are required")
+	}
+
+	if in.Expiration != "" {
+		info.Expiration, err = time.Parse(expirationFormat, in.Expiration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration %s: %v", in.Expiration, err)
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,111 @@
+package vex
+
+import (
+	"fmt"
+
+	"github.com/tsfdsong/eos-go"
+	"github.com/tsfdsong/eos-go/system"
+)
+
+//vexcoreTx transaction of a single system contract action authorized by actor
+func vexcoreTx(info *txInfo, name string, actor eos.AccountName, actionData interface{}, key *txKey, perm string) (string, error) {
+	return contractTx(info, "vexcore", name, eos.PermissionLevel{Actor: actor, Permission: eos.PN(perm)}, actionData, key)
+}
+
+//contractTx transaction of a single action of contract authorized by auth
+func contractTx(info *txInfo, contract, name string, auth eos.PermissionLevel, actionData interface{}, key *txKey) (string, error) {
+	txOpts := &eos.TxOptions{
+		ChainID:     info.ChainID,
+		HeadBlockID: info.HeadBlockID,
+	}
+
+	act := &eos.Action{
+		Account:       eos.AN(contract),
+		Name:          eos.ActN(name),
+		Authorization: []eos.PermissionLevel{auth},
+		ActionData:    eos.NewActionData(actionData),
+	}
+
+	tx := eos.NewTransaction([]*eos.Action{act}, txOpts)
+
+	data, err := getRawTxData(tx, info, key)
+	if err != nil {
+		return "", fmt.Errorf("%s %v", name, err)
+	}
+
+	return data, nil
+}
+
+//refund claim tokens of undelegatebw after the refund delay
+func refund(info *txInfo, in *RefundInfo, key *txKey, perm string) (string, error) {
+	owner := eos.AccountName(in.Owner)
+
+	return vexcoreTx(info, "refund", owner, system.Refund{Owner: owner}, key, perm)
+}
+
+//rexFund deposit VEX to or withdraw VEX from REX fund
+func rexFund(info *txInfo, name string, in *REXInfo, key *txKey, perm string) (string, error) {
+	amount, err := newSymbolAsset(in.Amount, VEXSymbol)
+	if err != nil {
+		return "", fmt.Errorf("%s %v", name, err)
+	}
+
+	owner := eos.AccountName(in.Owner)
+
+	return vexcoreTx(info, name, owner, rexAmount{Owner: owner, Amount: amount}, key, perm)
+}
+
+//buyREX buy REX with VEX of REX fund
+func buyREX(info *txInfo, in *REXInfo, key *txKey, perm string) (string, error) {
+	amount, err := newSymbolAsset(in.Amount, VEXSymbol)
+	if err != nil {
+		return "", fmt.Errorf("buyrex %v", err)
+	}
+
+	from := eos.AccountName(in.Owner)
+
+	return vexcoreTx(info, "buyrex", from, rexBuy{From: from, Amount: amount}, key, perm)
+}
+
+//sellREX sell REX into REX fund
+func sellREX(info *txInfo, in *REXInfo, key *txKey, perm string) (string, error) {
+	rex, err := newSymbolAsset(in.Amount, REXSymbol)
+	if err != nil {
+		return "", fmt.Errorf("sellrex %v", err)
+	}
+
+	from := eos.AccountName(in.Owner)
+
+	return vexcoreTx(info, "sellrex", from, rexSell{From: from, REX: rex}, key, perm)
+}
+
+//rentResource rentcpu or rentnet paid from REX fund
+func rentResource(info *txInfo, name string, in *RentInfo, key *txKey, perm string) (string, error) {
+	payment, err := newSymbolAsset(in.LoanPayment, VEXSymbol)
+	if err != nil {
+		return "", fmt.Errorf("%s loan payment %v", name, err)
+	}
+
+	if payment.Amount <= 0 {
+		return "", fmt.Errorf("%s loan payment should be positive", name)
+	}
+
+	fundStr := in.LoanFund
+	if fundStr == "" {
+		fundStr = "0.0000 VEX"
+	}
+
+	fund, err := newSymbolAsset(fundStr, VEXSymbol)
+	if err != nil {
+		return "", fmt.Errorf("%s loan fund %v", name, err)
+	}
+
+	from := eos.AccountName(in.From)
+
+	return vexcoreTx(info, name, from, rexRent{
+		From:        from,
+		Receiver:    eos.AccountName(in.Receiver),
+		LoanPayment: payment,
+		LoanFund:    fund,
+	}, key, perm)
+}
@@ -2,12 +2,17 @@ package vex
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/tsfdsong/atoken-app-sdk/hdwallet"
 	"github.com/tsfdsong/eos-go"
+	"github.com/tsfdsong/eos-go/ecc"
 )
 
 func TestVexTransfer(t *testing.T) {
@@ -28,7 +33,7 @@ func TestVexTransfer(t *testing.T) {
 		return
 	}
 
-	tx, err := transferAmount(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := transferAmount(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("transferAmount: %v\n", err)
 		return
@@ -102,7 +107,7 @@ func TestVexNewAccount(t *testing.T) {
 		return
 	}
 
-	tx, err := createAccount(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := createAccount(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("createAccount: %v\n", err)
 		return
@@ -148,7 +153,7 @@ func TestVexSellRAM(t *testing.T) {
 	byda, _ := json.Marshal(&info)
 	fmt.Printf("%v\n", string(byda))
 
-	tx, err := sellRAM(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := sellRAM(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("sellRAM: %v\n", err)
 		return
@@ -189,7 +194,7 @@ func TestVexBuyRAM(t *testing.T) {
 	byda, _ := json.Marshal(&info)
 	fmt.Printf("%v\n", string(byda))
 
-	tx, err := buyRAM(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := buyRAM(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("sellRAM: %v\n", err)
 		return
@@ -232,7 +237,7 @@ func TestVexDelegateBWNoTransfer(t *testing.T) {
 	byda, _ := json.Marshal(&info)
 	fmt.Printf("%v\n", string(byda))
 
-	tx, err := delegateBW(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := delegateBW(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("delegateBW: %v\n", err)
 		return
@@ -275,7 +280,7 @@ func TestVexUnDelegateBWNoTransfer(t *testing.T) {
 	byda, _ := json.Marshal(&info)
 	fmt.Printf("%v\n", string(byda))
 
-	tx, err := unDelegateBW(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := unDelegateBW(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("unDelegateBW: %v\n", err)
 		return
@@ -318,7 +323,7 @@ func TestVexDelegateBWTransfer(t *testing.T) {
 	byda, _ := json.Marshal(&info)
 	fmt.Printf("%v\n", string(byda))
 
-	tx, err := delegateBW(info, input, "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	tx, err := delegateBW(infoOf(info), input, wifKey("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"), "active")
 	if err != nil {
 		t.Errorf("delegateBW: %v\n", err)
 		return
@@ -361,7 +366,7 @@ func TestVexUnDelegateBWTransfer(t *testing.T) {
 	byda, _ := json.Marshal(&info)
 	fmt.Printf("%v\n", string(byda))
 
-	tx, err := unDelegateBW(info, input, "5JLYhubP9whvSjUsPMGtm9bkHCPEpFa9QNFKfSdxU9kpewApixj", "active")
+	tx, err := unDelegateBW(infoOf(info), input, wifKey("5JLYhubP9whvSjUsPMGtm9bkHCPEpFa9QNFKfSdxU9kpewApixj"), "active")
 	if err != nil {
 		t.Errorf("unDelegateBW: %v\n", err)
 		return
@@ -381,3 +386,789 @@ func TestVexUnDelegateBWTransfer(t *testing.T) {
 
 	fmt.Printf("unDelegateBW Transaction [%s] submitted to the network succesfully.\n", hex.EncodeToString(response.Processed.ID))
 }
+
+const testABI = `{
+	"version": "eosio::abi/1.0",
+	"structs": [{
+		"name": "play",
+		"base": "",
+		"fields": [
+			{"name": "player", "type": "name"},
+			{"name": "bet", "type": "asset"},
+			{"name": "seed", "type": "uint64"},
+			{"name": "memo", "type": "string"}
+		]
+	}],
+	"actions": [{"name": "play", "type": "play", "ricardian_contract": ""}]
+}`
+
+const testInfo = `{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f",
+"head_block_id":"0000b4e07e0a1c4a0b8f5e1bc0f3d2a1f3c4b5a6978877665544332211009988"}`
+
+//testAction the only action of packed transaction tx
+func testAction(t *testing.T, tx string) *eos.Action {
+	var packedTx eos.PackedTransaction
+	if err := json.Unmarshal([]byte(tx), &packedTx); err != nil {
+		t.Fatalf("Unmarshal: %v\n", err)
+	}
+
+	sigTx, err := packedTx.Unpack()
+	if err != nil {
+		t.Fatalf("Unpack: %v\n", err)
+	}
+
+	if len(sigTx.Actions) != 1 {
+		t.Fatalf("transaction has %d actions\n", len(sigTx.Actions))
+	}
+
+	return sigTx.Actions[0]
+}
+
+//checkAction contract, name, authorization "actor@permission" and hex data of the only action of tx
+func checkAction(t *testing.T, tx, account, name, auth, data string) {
+	act := testAction(t, tx)
+	if string(act.Account) != account || string(act.Name) != name {
+		t.Errorf("action %s::%s, expected %s::%s\n", act.Account, act.Name, account, name)
+	}
+
+	if len(act.Authorization) != 1 || fmt.Sprintf("%s@%s", act.Authorization[0].Actor, act.Authorization[0].Permission) != auth {
+		t.Errorf("%s authorization %v, expected %s\n", name, act.Authorization, auth)
+	}
+
+	if hex.EncodeToString(act.HexData) != data {
+		t.Errorf("%s data %x, expected %s\n", name, act.HexData, data)
+	}
+}
+
+//nameHex serialized name, uint64 little endian
+func nameHex(name string) string {
+	value, _ := eos.StringToName(name)
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, value)
+
+	return hex.EncodeToString(b)
+}
+
+//assetHex serialized asset, amount in the smallest unit then precision and code
+func assetHex(amount int64, precision byte, code string) string {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b, uint64(amount))
+	b[8] = precision
+	copy(b[9:], code)
+
+	return hex.EncodeToString(b)
+}
+
+func TestVexCustomAction(t *testing.T) {
+	acts := []ActionInfo{{
+		Account:       "dicegame",
+		Name:          "play",
+		Authorization: []PermissionInfo{{Actor: "atokentry123"}},
+		Data:          json.RawMessage(`{"player":"atokentry123","bet":"1.0000 VEX","seed":42,"memo":"hi"}`),
+		ABI:           json.RawMessage(`{"account_name":"dicegame","abi":` + testABI + `}`),
+	}}
+
+	data, _ := json.Marshal(acts)
+	tx, err := VexAPI(tVEXTransferTypeAction, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI action: %v\n", err)
+	}
+	fmt.Printf("action tx: %v\n", tx)
+
+	var packedTx eos.PackedTransaction
+	if err := json.Unmarshal([]byte(tx), &packedTx); err != nil {
+		t.Fatalf("Unmarshal: %v\n", err)
+	}
+
+	//name atokentry123, 1.0000 VEX, seed 42, memo "hi"
+	abi, _ := loadABI(json.RawMessage(testABI))
+	actData, _ := abi.EncodeAction(eos.ActN("play"), acts[0].Data)
+	if !strings.Contains(hex.EncodeToString(packedTx.PackedTransaction), hex.EncodeToString(actData)) {
+		t.Errorf("packed transaction does not carry action data %x\n", actData)
+	}
+
+	acts[0].Name = "unknown"
+	data, _ = json.Marshal(acts)
+	if _, err := VexAPI(tVEXTransferTypeAction, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err == nil {
+		t.Errorf("action missing in abi should fail\n")
+	}
+}
+
+func TestVexBatchTransfer(t *testing.T) {
+	trans := []TransferInfo{
+		{From: "atokentry123", To: "atokenmai123", Quantity: "1.0000 VEX", Memo: "vex"},
+		{From: "atokentry123", To: "atokenmai123", Quantity: "2.50 GAME", Memo: "game", Contract: "gametoken", Symbol: "2,GAME"},
+	}
+
+	data, _ := json.Marshal(trans)
+	tx, err := VexAPI(tVEXTransferTypeBatchTransfer, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI batch transfer: %v\n", err)
+	}
+	fmt.Printf("batch transfer tx: %v\n", tx)
+
+	for _, in := range []TransferInfo{
+		{From: "atokentry123", To: "atokenmai123", Quantity: "2.5 GAME", Contract: "gametoken", Symbol: "2,GAME"},
+		{From: "atokentry123", To: "atokenmai123", Quantity: "2.50 GAME", Contract: "gametoken", Symbol: "2,GOLD"},
+		{From: "atokentry123", To: "atokenmai123", Quantity: "1.0000 VEX", Symbol: "4,vex"},
+		{From: "atokentry123", To: "atokenmai123", Quantity: "1.00 VEX", Symbol: "4,VEX"},
+	} {
+		if _, err := newTransferAction(&in, "active"); err == nil {
+			t.Errorf("quantity %s of symbol %s should be rejected\n", in.Quantity, in.Symbol)
+		}
+	}
+
+	//VEX quantity without symbol is padded to 4 decimals
+	for quantity, amount := range map[string]int64{"1 VEX": 10000, "1.5 VEX": 15000, "1.00 VEX": 10000} {
+		data, _ := json.Marshal(TransferInfo{From: "atokentry123", To: "atokenmai123", Quantity: quantity})
+		tx, err := VexAPI(tVEXTransferTypeTransferAmount, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+		if err != nil {
+			t.Errorf("VexAPI transfer %s: %v\n", quantity, err)
+			continue
+		}
+
+		checkAction(t, tx, "vex.token", "transfer", "atokentry123@active",
+			nameHex("atokentry123")+nameHex("atokenmai123")+assetHex(amount, 4, "VEX")+"00")
+	}
+}
+
+func TestVexVoteProducer(t *testing.T) {
+	producers, err := sortProducers([]string{"vexproducer2", "atokenbp1111", "vexproducer1"})
+	if err != nil {
+		t.Fatalf("sortProducers: %v\n", err)
+	}
+
+	if producers[0] != "atokenbp1111" || producers[1] != "vexproducer1" || producers[2] != "vexproducer2" {
+		t.Errorf("producers are not sorted: %v\n", producers)
+	}
+
+	if _, err := sortProducers([]string{"vexproducer1", "vexproducer1"}); err == nil {
+		t.Errorf("duplicate producers should fail\n")
+	}
+
+	vote := VoteProducerInfo{Voter: "atokentry123", Producers: []string{"vexproducer2", "vexproducer1"}}
+	data, _ := json.Marshal(vote)
+	tx, err := VexAPI(tVEXTransferTypeVoteProducer, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI voteproducer: %v\n", err)
+	}
+	fmt.Printf("voteproducer tx: %v\n", tx)
+
+	vote.Proxy = "atokenproxy1"
+	data, _ = json.Marshal(vote)
+	if _, err := VexAPI(tVEXTransferTypeVoteProducer, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err == nil {
+		t.Errorf("voting producers and proxy at once should fail\n")
+	}
+
+	data, _ = json.Marshal(RegProxyInfo{Proxy: "atokentry123", IsProxy: 1})
+	if _, err := VexAPI(tVEXTransferTypeRegProxy, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err != nil {
+		t.Errorf("VexAPI regproxy: %v\n", err)
+	}
+}
+
+func TestVexRefund(t *testing.T) {
+	data, _ := json.Marshal(RefundInfo{Owner: "atokentry123"})
+	tx, err := VexAPI(tVEXTransferTypeRefund, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI refund: %v\n", err)
+	}
+	fmt.Printf("refund tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "refund", "atokentry123@active", nameHex("atokentry123"))
+}
+
+func TestVexREXFund(t *testing.T) {
+	data, _ := json.Marshal(REXInfo{Owner: "atokentry123", Amount: "10.0000 VEX"})
+	tx, err := VexAPI(tVEXTransferTypeREXDeposit, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI deposit: %v\n", err)
+	}
+	fmt.Printf("deposit tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "deposit", "atokentry123@active", nameHex("atokentry123")+assetHex(100000, 4, "VEX"))
+
+	data, _ = json.Marshal(REXInfo{Owner: "atokentry123", Amount: "1.0000 VEX"})
+	tx, err = VexAPI(tVEXTransferTypeREXWithdraw, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI withdraw: %v\n", err)
+	}
+	fmt.Printf("withdraw tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "withdraw", "atokentry123@active", nameHex("atokentry123")+assetHex(10000, 4, "VEX"))
+
+	data, _ = json.Marshal(REXInfo{Owner: "atokentry123", Amount: "1.0000 REX"})
+	if _, err := VexAPI(tVEXTransferTypeREXDeposit, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err == nil {
+		t.Errorf("depositing REX should fail\n")
+	}
+}
+
+func TestVexBuySellREX(t *testing.T) {
+	data, _ := json.Marshal(REXInfo{Owner: "atokentry123", Amount: "5.0000 VEX"})
+	tx, err := VexAPI(tVEXTransferTypeBuyREX, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI buyrex: %v\n", err)
+	}
+	fmt.Printf("buyrex tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "buyrex", "atokentry123@active", nameHex("atokentry123")+assetHex(50000, 4, "VEX"))
+
+	data, _ = json.Marshal(REXInfo{Owner: "atokentry123", Amount: "100.0000 REX"})
+	tx, err = VexAPI(tVEXTransferTypeSellREX, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI sellrex: %v\n", err)
+	}
+	fmt.Printf("sellrex tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "sellrex", "atokentry123@active", nameHex("atokentry123")+assetHex(1000000, 4, "REX"))
+
+	data, _ = json.Marshal(REXInfo{Owner: "atokentry123", Amount: "100.0000 VEX"})
+	if _, err := VexAPI(tVEXTransferTypeSellREX, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err == nil {
+		t.Errorf("selling VEX as REX should fail\n")
+	}
+}
+
+func TestVexRentResource(t *testing.T) {
+	rent := RentInfo{From: "atokentry123", Receiver: "atokenmai123", LoanPayment: "0.1000 VEX"}
+	data, _ := json.Marshal(rent)
+	tx, err := VexAPI(tVEXTransferTypeRentCPU, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI rentcpu: %v\n", err)
+	}
+	fmt.Printf("rentcpu tx: %v\n", tx)
+
+	//loan fund is 0.0000 VEX by default
+	checkAction(t, tx, "vexcore", "rentcpu", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("atokenmai123")+assetHex(1000, 4, "VEX")+assetHex(0, 4, "VEX"))
+
+	rent.LoanFund = "0.0500 VEX"
+	data, _ = json.Marshal(rent)
+	tx, err = VexAPI(tVEXTransferTypeRentNET, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI rentnet: %v\n", err)
+	}
+	fmt.Printf("rentnet tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "rentnet", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("atokenmai123")+assetHex(1000, 4, "VEX")+assetHex(500, 4, "VEX"))
+
+	rent.LoanPayment = "0.0000 VEX"
+	data, _ = json.Marshal(rent)
+	if _, err := VexAPI(tVEXTransferTypeRentNET, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err == nil {
+		t.Errorf("renting with zero payment should fail\n")
+	}
+}
+
+//uintHex serialized unsigned integer of size bytes, little endian
+func uintHex(v uint64, size int) string {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+
+	return hex.EncodeToString(b[:size])
+}
+
+func TestVexUpdateAuth(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+	pubKey := "VEX5awQ9H9Tj2qorH1ETLDDynvdQgxxFZepfr1iS5EU3WtWvzyfe1"
+
+	key, err := ecc.NewPublicKey(pubKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v\n", err)
+	}
+	//curve K1 then compressed key
+	keyHex := "00" + hex.EncodeToString(key.Content)
+
+	//recovery owner with keys, accounts and waits given out of order
+	in := UpdateAuthInfo{
+		Account:    "atokentry123",
+		Permission: "owner",
+		Auth: AuthorityInfo{
+			Threshold: 2,
+			Keys:      []KeyWeightInfo{{PublicKey: pubKey, Weight: 1}},
+			Accounts: []eos.PermissionLevelWeight{
+				{Permission: eos.PermissionLevel{Actor: "atokenmai123", Permission: "active"}, Weight: 1},
+				{Permission: eos.PermissionLevel{Actor: "atokenbob123", Permission: "owner"}, Weight: 1},
+			},
+			Waits: []eos.WaitWeight{{WaitSec: 86400, Weight: 1}, {WaitSec: 3600, Weight: 1}},
+		},
+	}
+	data, _ := json.Marshal(in)
+	tx, err := VexAPI(tVEXTransferTypeUpdateAuth, testInfo, string(data), wif, "owner")
+	if err != nil {
+		t.Fatalf("VexAPI updateauth owner: %v\n", err)
+	}
+	fmt.Printf("updateauth owner tx: %v\n", tx)
+
+	//owner has no parent, accounts and waits are sorted
+	checkAction(t, tx, "vexcore", "updateauth", "atokentry123@owner",
+		nameHex("atokentry123")+nameHex("owner")+uintHex(0, 8)+uintHex(2, 4)+
+			"01"+keyHex+uintHex(1, 2)+
+			"02"+nameHex("atokenbob123")+nameHex("owner")+uintHex(1, 2)+nameHex("atokenmai123")+nameHex("active")+uintHex(1, 2)+
+			"02"+uintHex(3600, 4)+uintHex(1, 2)+uintHex(86400, 4)+uintHex(1, 2))
+
+	hot := UpdateAuthInfo{
+		Account:    "atokentry123",
+		Permission: "hot",
+		Parent:     "active",
+		Auth:       AuthorityInfo{Threshold: 1, Keys: []KeyWeightInfo{{PublicKey: pubKey, Weight: 1}}},
+	}
+	data, _ = json.Marshal(hot)
+	tx, err = VexAPI(tVEXTransferTypeUpdateAuth, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI updateauth hot: %v\n", err)
+	}
+	fmt.Printf("updateauth hot tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "updateauth", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("hot")+nameHex("active")+uintHex(1, 4)+"01"+keyHex+uintHex(1, 2)+"00"+"00")
+
+	bad := []UpdateAuthInfo{
+		//no parent
+		{Account: "atokentry123", Permission: "hot", Auth: hot.Auth},
+		//threshold can not be reached
+		{Account: "atokentry123", Permission: "hot", Parent: "active", Auth: AuthorityInfo{Threshold: 2, Keys: hot.Auth.Keys}},
+		//duplicate key
+		{Account: "atokentry123", Permission: "hot", Parent: "active", Auth: AuthorityInfo{Threshold: 1, Keys: append(hot.Auth.Keys, hot.Auth.Keys...)}},
+	}
+	for i, in := range bad {
+		data, _ := json.Marshal(in)
+		if _, err := VexAPI(tVEXTransferTypeUpdateAuth, testInfo, string(data), wif, "active"); err == nil {
+			t.Errorf("bad updateauth %d should fail\n", i)
+		}
+	}
+}
+
+func TestVexDeleteAuth(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+
+	data, _ := json.Marshal(DeleteAuthInfo{Account: "atokentry123", Permission: "hot"})
+	tx, err := VexAPI(tVEXTransferTypeDeleteAuth, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI deleteauth: %v\n", err)
+	}
+	fmt.Printf("deleteauth tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "deleteauth", "atokentry123@active", nameHex("atokentry123")+nameHex("hot"))
+
+	data, _ = json.Marshal(DeleteAuthInfo{Account: "atokentry123", Permission: "active"})
+	if _, err := VexAPI(tVEXTransferTypeDeleteAuth, testInfo, string(data), wif, "owner"); err == nil {
+		t.Errorf("deleting active should fail\n")
+	}
+}
+
+func TestVexLinkAuth(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+
+	data, _ := json.Marshal(LinkAuthInfo{Account: "atokentry123", Code: "vex.token", Type: "transfer", Requirement: "hot"})
+	tx, err := VexAPI(tVEXTransferTypeLinkAuth, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI linkauth: %v\n", err)
+	}
+	fmt.Printf("linkauth tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "linkauth", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("vex.token")+nameHex("transfer")+nameHex("hot"))
+
+	data, _ = json.Marshal(LinkAuthInfo{Account: "atokentry123", Code: "vex.token", Type: "transfer", Requirement: "active"})
+	if _, err := VexAPI(tVEXTransferTypeLinkAuth, testInfo, string(data), wif, "active"); err == nil {
+		t.Errorf("linking to active should fail\n")
+	}
+
+	data, _ = json.Marshal(UnlinkAuthInfo{Account: "atokentry123", Code: "vex.token", Type: "transfer"})
+	tx, err = VexAPI(tVEXTransferTypeUnlinkAuth, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI unlinkauth: %v\n", err)
+	}
+	fmt.Printf("unlinkauth tx: %v\n", tx)
+
+	checkAction(t, tx, "vexcore", "unlinkauth", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("vex.token")+nameHex("transfer"))
+}
+
+func TestVexMsigPropose(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+	info := `{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f",
+"ref_block_id":"0000b4e07e0a1c4a0b8f5e1bc0f3d2a1f3c4b5a6978877665544332211009988",
+"expiration":"2026-10-19T08:00:00"}`
+
+	in := ProposeInfo{
+		Proposer:     "atokentry123",
+		ProposalName: "pay",
+		Requested:    []PermissionInfo{{Actor: "atokentry123"}, {Actor: "atokenmai123"}},
+		Actions: []ActionInfo{{
+			Account:       "dicegame",
+			Name:          "play",
+			Authorization: []PermissionInfo{{Actor: "corporate123"}},
+			Data:          json.RawMessage(`{"player":"corporate123","bet":"1.0000 VEX","seed":42,"memo":"hi"}`),
+			ABI:           json.RawMessage(testABI),
+		}},
+	}
+	data, _ := json.Marshal(in)
+	tx, err := VexOfflineAPI(tVEXTransferTypeMsigPropose, info, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexOfflineAPI propose: %v\n", err)
+	}
+	fmt.Printf("propose tx: %v\n", tx)
+
+	abi, _ := loadABI(json.RawMessage(testABI))
+	actData, _ := abi.EncodeAction(eos.ActN("play"), in.Actions[0].Data)
+
+	//proposed transaction expires 24 hours later, refers to no block, has no resource limit and delay
+	trx := uintHex(uint64(time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC).Unix()), 4) + uintHex(0, 2) + uintHex(0, 4) + "00" + "00" + "00" +
+		"00" + "01" + nameHex("dicegame") + nameHex("play") + "01" + nameHex("corporate123") + nameHex("active") +
+		fmt.Sprintf("%02x", len(actData)) + hex.EncodeToString(actData) + "00"
+	checkAction(t, tx, "vex.msig", "propose", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("pay")+"02"+nameHex("atokentry123")+nameHex("active")+nameHex("atokenmai123")+nameHex("active")+trx)
+
+	bad := []ProposeInfo{
+		//no requested permission
+		{Proposer: "atokentry123", ProposalName: "pay", Actions: in.Actions},
+		//invalid proposal name
+		{Proposer: "atokentry123", ProposalName: "Pay", Requested: in.Requested, Actions: in.Actions},
+		//no action
+		{Proposer: "atokentry123", ProposalName: "pay", Requested: in.Requested},
+	}
+	for i, in := range bad {
+		data, _ := json.Marshal(in)
+		if _, err := VexOfflineAPI(tVEXTransferTypeMsigPropose, info, string(data), wif, "active"); err == nil {
+			t.Errorf("bad propose %d should fail\n", i)
+		}
+	}
+}
+
+func TestVexMsigApprove(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+
+	//level permission is perm by default and authorizes the action
+	data, _ := json.Marshal(ApproveInfo{Proposer: "atokentry123", ProposalName: "pay", Level: PermissionInfo{Actor: "atokenmai123"}})
+	tx, err := VexAPI(tVEXTransferTypeMsigApprove, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI approve: %v\n", err)
+	}
+	fmt.Printf("approve tx: %v\n", tx)
+
+	checkAction(t, tx, "vex.msig", "approve", "atokenmai123@active",
+		nameHex("atokentry123")+nameHex("pay")+nameHex("atokenmai123")+nameHex("active"))
+
+	data, _ = json.Marshal(ApproveInfo{Proposer: "atokentry123", ProposalName: "pay", Level: PermissionInfo{Actor: "atokenmai123", Permission: "owner"}})
+	tx, err = VexAPI(tVEXTransferTypeMsigUnapprove, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI unapprove: %v\n", err)
+	}
+	fmt.Printf("unapprove tx: %v\n", tx)
+
+	checkAction(t, tx, "vex.msig", "unapprove", "atokenmai123@owner",
+		nameHex("atokentry123")+nameHex("pay")+nameHex("atokenmai123")+nameHex("owner"))
+}
+
+func TestVexMsigExec(t *testing.T) {
+	data, _ := json.Marshal(ExecInfo{Proposer: "atokentry123", ProposalName: "pay", Executer: "atokenmai123"})
+	tx, err := VexAPI(tVEXTransferTypeMsigExec, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI exec: %v\n", err)
+	}
+	fmt.Printf("exec tx: %v\n", tx)
+
+	checkAction(t, tx, "vex.msig", "exec", "atokenmai123@active",
+		nameHex("atokentry123")+nameHex("pay")+nameHex("atokenmai123"))
+}
+
+func TestVexMsigCancel(t *testing.T) {
+	data, _ := json.Marshal(CancelInfo{Proposer: "atokentry123", ProposalName: "pay", Canceler: "atokentry123"})
+	tx, err := VexAPI(tVEXTransferTypeMsigCancel, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active")
+	if err != nil {
+		t.Fatalf("VexAPI cancel: %v\n", err)
+	}
+	fmt.Printf("cancel tx: %v\n", tx)
+
+	checkAction(t, tx, "vex.msig", "cancel", "atokentry123@active",
+		nameHex("atokentry123")+nameHex("pay")+nameHex("atokentry123"))
+
+	data, _ = json.Marshal(CancelInfo{Proposer: "atokentry123", Canceler: "atokentry123"})
+	if _, err := VexAPI(tVEXTransferTypeMsigCancel, testInfo, string(data), "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", "active"); err == nil {
+		t.Errorf("cancel without proposal name should fail\n")
+	}
+}
+
+func TestVexAddSignature(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+	cosigner := "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"
+
+	//shared account of two keys signs the action directly
+	data, _ := json.Marshal([]ActionInfo{{
+		Account:       "dicegame",
+		Name:          "play",
+		Authorization: []PermissionInfo{{Actor: "corporate123"}},
+		Data:          json.RawMessage(`{"player":"corporate123","bet":"1.0000 VEX","seed":42,"memo":"hi"}`),
+		ABI:           json.RawMessage(testABI),
+	}})
+	tx, err := VexAPI(tVEXTransferTypeAction, testInfo, string(data), wif, "active")
+	if err != nil {
+		t.Fatalf("VexAPI action: %v\n", err)
+	}
+
+	chainID := "f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f"
+	signed, err := AddVexSignature(tx, chainID, cosigner)
+	if err != nil {
+		t.Fatalf("AddVexSignature: %v\n", err)
+	}
+
+	var before, after eos.PackedTransaction
+	json.Unmarshal([]byte(tx), &before)
+	json.Unmarshal([]byte(signed), &after)
+	if len(after.Signatures) != 2 || hex.EncodeToString(before.PackedTransaction) != hex.EncodeToString(after.PackedTransaction) {
+		t.Errorf("signature is not added to the same transaction: %v\n", signed)
+	}
+
+	if _, err := AddVexSignature(signed, chainID, cosigner); err == nil {
+		t.Errorf("signing twice with one key should fail\n")
+	}
+
+	//cosigner of external signer keeps the transaction whatever its first signature is
+	key, _, err := hdwallet.DecodeWIF(cosigner, nil)
+	if err != nil {
+		t.Fatalf("DecodeWIF: %v\n", err)
+	}
+	keySigner, _ := hdwallet.NewHexKeySigner(hex.EncodeToString(key.Serialize()))
+	cosignerKey, _ := ecc.NewPrivateKey(cosigner)
+	id, _ := hex.DecodeString(chainID)
+
+	retried := 0
+	for i := 0; i < 32; i++ {
+		transfer := fmt.Sprintf(`{"from":"corporate123","to":"atokenmai123","quantity":"1.0000 VEX","memo":"payroll %d"}`, i)
+		tx, err := VexAPI(tVEXTransferTypeTransferAmount, testInfo, transfer, wif, "active")
+		if err != nil {
+			t.Fatalf("VexAPI transfer: %v\n", err)
+		}
+
+		signer := hdwallet.NewMockSigner(keySigner)
+		signed, err := AddVexSignatureWithSigner(tx, chainID, "", signer)
+		if err != nil {
+			t.Errorf("memo %d: AddVexSignatureWithSigner: %v\n", i, err)
+			continue
+		}
+		if signer.SignCount() > 1 {
+			retried++
+		}
+
+		var before, after eos.PackedTransaction
+		json.Unmarshal([]byte(tx), &before)
+		json.Unmarshal([]byte(signed), &after)
+		if len(after.Signatures) != 2 || hex.EncodeToString(before.PackedTransaction) != hex.EncodeToString(after.PackedTransaction) {
+			t.Errorf("memo %d: signature is not added to the same transaction: %v\n", i, signed)
+		}
+
+		//added signature recovers the cosigner key
+		pubKey, err := after.Signatures[1].PublicKey(eos.SigDigest(id, after.PackedTransaction, nil))
+		if err != nil || pubKey.String() != cosignerKey.PublicKey().String() {
+			t.Errorf("memo %d: signature is not of cosigner: %v\n", i, err)
+		}
+	}
+
+	if retried == 0 {
+		t.Errorf("no non-canonical signature met in 32 transactions\n")
+	}
+}
+
+func TestVexOffline(t *testing.T) {
+	wif := "5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE"
+	transfer := `{"from":"atokentry123","to":"atokenmai123","quantity":"1.0000 VEX","memo":"offline"}`
+
+	byID := `{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f",
+"ref_block_id":"0000b4e07e0a1c4a0b8f5e1bc0f3d2a1f3c4b5a6978877665544332211009988",
+"expiration":"2026-10-19T08:00:00","delay_sec":60}`
+
+	tx, err := VexOfflineAPI(tVEXTransferTypeTransferAmount, byID, transfer, wif, "active")
+	if err != nil {
+		t.Fatalf("VexOfflineAPI: %v\n", err)
+	}
+	fmt.Printf("offline tx: %v\n", tx)
+
+	var packedTx eos.PackedTransaction
+	if err := json.Unmarshal([]byte(tx), &packedTx); err != nil {
+		t.Fatalf("Unmarshal: %v\n", err)
+	}
+
+	sigTx, err := packedTx.Unpack()
+	if err != nil {
+		t.Fatalf("Unpack: %v\n", err)
+	}
+
+	if sigTx.Expiration.UTC().Format(expirationFormat) != "2026-10-19T08:00:00" || sigTx.DelaySec != 60 ||
+		sigTx.RefBlockNum != 0xb4e0 {
+		t.Errorf("unexpected header: %v %v %v\n", sigTx.Expiration, sigTx.DelaySec, sigTx.RefBlockNum)
+	}
+
+	//same header from ref block num and prefix gives the same transaction
+	byNum := fmt.Sprintf(`{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f",
+"ref_block_num":%d,"ref_block_prefix":%d,"expiration":"2026-10-19T08:00:00","delay_sec":60}`, 0xb4e0, sigTx.RefBlockPrefix)
+
+	again, err := VexOfflineAPI(tVEXTransferTypeTransferAmount, byNum, transfer, wif, "active")
+	if err != nil {
+		t.Fatalf("VexOfflineAPI: %v\n", err)
+	}
+
+	if again != tx {
+		t.Errorf("offline transaction is not deterministic: %v\n", again)
+	}
+
+	//offline proposal expires ExpireHours after the explicit expiration
+	proposal, _ := json.Marshal(ProposeInfo{
+		Proposer:     "atokentry123",
+		ProposalName: "pay",
+		Requested:    []PermissionInfo{{Actor: "atokenmai123"}},
+		Actions: []ActionInfo{{
+			Account:       "dicegame",
+			Name:          "play",
+			Authorization: []PermissionInfo{{Actor: "corporate123"}},
+			Data:          json.RawMessage(`{"player":"corporate123","bet":"1.0000 VEX","seed":42,"memo":"hi"}`),
+			ABI:           json.RawMessage(testABI),
+		}},
+		ExpireHours: 48,
+	})
+
+	proposeTx, err := VexOfflineAPI(tVEXTransferTypeMsigPropose, byID, string(proposal), wif, "active")
+	if err != nil {
+		t.Fatalf("VexOfflineAPI propose: %v\n", err)
+	}
+
+	proposeAgain, _ := VexOfflineAPI(tVEXTransferTypeMsigPropose, byID, string(proposal), wif, "active")
+	if proposeAgain != proposeTx {
+		t.Errorf("offline proposal is not deterministic: %v\n", proposeAgain)
+	}
+
+	var packedPropose eos.PackedTransaction
+	json.Unmarshal([]byte(proposeTx), &packedPropose)
+
+	//2026-10-21T08:00:00 as little endian seconds
+	innerExpiration := make([]byte, 4)
+	binary.LittleEndian.PutUint32(innerExpiration, uint32(time.Date(2026, 10, 21, 8, 0, 0, 0, time.UTC).Unix()))
+	if !strings.Contains(hex.EncodeToString(packedPropose.PackedTransaction), hex.EncodeToString(innerExpiration)) {
+		t.Errorf("proposed transaction should expire at 2026-10-21T08:00:00\n")
+	}
+
+	//block 0x1b4e0 is above 65535, ref_block_num of its header is 0xb4e0
+	high := `{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f",
+"ref_block_id":"0001b4e07e0a1c4a0b8f5e1bc0f3d2a1f3c4b5a6978877665544332211009988","ref_block_num":%d}`
+	for _, num := range []int{0x1b4e0, 0xb4e0} {
+		if _, err := VexOfflineAPI(tVEXTransferTypeTransferAmount, fmt.Sprintf(high, num), transfer, wif, "active"); err != nil {
+			t.Errorf("ref block num %d of block 0x1b4e0: %v\n", num, err)
+		}
+	}
+
+	bad := []string{
+		`{"chain_id":"f9f4","ref_block_id":"0000b4e07e0a1c4a0b8f5e1bc0f3d2a1f3c4b5a6978877665544332211009988"}`,
+		fmt.Sprintf(high, 0xb4e1),
+		`{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f"}`,
+		`{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f","ref_block_num":10,"ref_block_prefix":1,"expiration":"tomorrow"}`,
+	}
+	for i, info := range bad {
+		if _, err := VexOfflineAPI(tVEXTransferTypeTransferAmount, info, transfer, wif, "active"); err == nil {
+			t.Errorf("case %d: invalid offline info should fail\n", i)
+		}
+	}
+}
+
+func TestVexOfflineSigner(t *testing.T) {
+	key, _, err := hdwallet.DecodeWIF("5JknoozotmRa18kRNcfYdNVHXPHTTf9pwzUaiVtDCBPpM2J73hE", nil)
+	if err != nil {
+		t.Fatalf("DecodeWIF: %v\n", err)
+	}
+
+	keySigner, _ := hdwallet.NewHexKeySigner(hex.EncodeToString(key.Serialize()))
+	info := `{"chain_id":"f9f432b1851b5c179d2091a96f593aaed50ec7466b74f89301f957a83e56ce1f",
+"ref_block_id":"0000b4e07e0a1c4a0b8f5e1bc0f3d2a1f3c4b5a6978877665544332211009988","expiration":"2026-10-19T08:00:00"}`
+
+	//explicit expiration is never moved, the signer signs the same digest again with another nonce
+	retried := 0
+	for i := 0; i < 32; i++ {
+		signer := hdwallet.NewMockSigner(keySigner)
+		transfer := fmt.Sprintf(`{"from":"atokentry123","to":"atokenmai123","quantity":"1.0000 VEX","memo":"offline %d"}`, i)
+
+		tx, err := VexOfflineAPIWithSigner(tVEXTransferTypeTransferAmount, info, transfer, "", "active", signer)
+		if err != nil {
+			t.Errorf("memo %d: %v\n", i, err)
+			continue
+		}
+
+		for j := 1; j < signer.SignCount(); j++ {
+			if signer.SignedDigest(j) != signer.SignedDigest(0) {
+				t.Errorf("memo %d: signed another digest with fixed expiration\n", i)
+			}
+		}
+		if signer.SignCount() > 1 {
+			retried++
+		}
+
+		var packedTx eos.PackedTransaction
+		json.Unmarshal([]byte(tx), &packedTx)
+		sigTx, err := packedTx.Unpack()
+		if err != nil || sigTx.Expiration.UTC().Format(expirationFormat) != "2026-10-19T08:00:00" {
+			t.Errorf("memo %d: expiration changed: %v\n", i, err)
+		}
+	}
+
+	if retried == 0 {
+		t.Errorf("no non-canonical signature met in 32 transactions\n")
+	}
+
+	//signer without nonce can not keep the expiration
+	rejected := 0
+	for i := 0; i < 32; i++ {
+		transfer := fmt.Sprintf(`{"from":"atokentry123","to":"atokenmai123","quantity":"1.0000 VEX","memo":"offline %d"}`, i)
+		if _, err := VexOfflineAPIWithSigner(tVEXTransferTypeTransferAmount, info, transfer, "", "active", plainSigner{keySigner}); err != nil {
+			if !strings.Contains(err.Error(), "expiration can not be changed") {
+				t.Errorf("memo %d: %v\n", i, err)
+			}
+			rejected++
+		}
+	}
+
+	if rejected != retried {
+		t.Errorf("%d plain signer rejections, expected %d\n", rejected, retried)
+	}
+}
+
+//plainSigner Signer without SignDigestWithNonce
+type plainSigner struct {
+	hdwallet.Signer
+}
+
+func TestVexWalletWIF(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	//VEX key is the uncompressed mainnet form on any network
+	for _, network := range []string{"mainnet", "testnet"} {
+		wallet, err := hdwallet.NewWalletWithNetwork(mnemonic, "VEX", network)
+		if err != nil {
+			t.Fatalf("NewWalletWithNetwork %s: %v\n", network, err)
+		}
+
+		wif, err := wallet.GetWIFPrivateKey("VEX", 0, false)
+		if err != nil {
+			t.Fatalf("GetWIFPrivateKey %s: %v\n", network, err)
+		}
+
+		if !strings.HasPrefix(wif, "5") {
+			t.Errorf("%s wif %s is not uncompressed mainnet form\n", network, wif)
+		}
+
+		privKey, err := ecc.NewPrivateKey(wif)
+		if err != nil {
+			t.Fatalf("NewPrivateKey %s: %v\n", network, err)
+		}
+
+		_, address, err := wallet.GetKeyAndAddress("VEX", 0, false)
+		if err != nil {
+			t.Fatalf("GetKeyAndAddress %s: %v\n", network, err)
+		}
+
+		pubKey, err := ecc.NewPublicKey(address)
+		if err != nil {
+			t.Fatalf("NewPublicKey %s: %v\n", network, err)
+		}
+
+		if hex.EncodeToString(privKey.PublicKey().Content) != hex.EncodeToString(pubKey.Content) {
+			t.Errorf("%s wif %s is not the key of %s\n", network, wif, address)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package vex
 
 import (
+	"encoding/json"
+
 	"github.com/tsfdsong/eos-go"
 	"github.com/tsfdsong/eos-go/ecc"
 )
@@ -9,12 +11,17 @@ import (
 // here just to speed up things.
 var VEXSymbol = eos.Symbol{Precision: 4, Symbol: "VEX"}
 
+//VEXTokenContract contract of VEX token
+const VEXTokenContract = "vex.token"
+
 //TransferInfo input parameter of transfer
 type TransferInfo struct {
 	From     string `json:"from"`
 	To       string `json:"to"`
 	Quantity string `json:"quantity"`
 	Memo     string `json:"memo"`
+	Contract string `json:"contract"` //token contract, vex.token by default
+	Symbol   string `json:"symbol"`   //precision and symbol like "4,VEX", VEXSymbol by default
 }
 
 //CreateAccountInfo create account info
@@ -94,3 +101,185 @@ type BuyRAMBytes struct {
 	Receiver string `json:"receiver"`
 	Bytes    uint64 `json:"bytes"`
 }
+
+//PermissionInfo actor and permission of action authorization
+type PermissionInfo struct {
+	Actor      string `json:"actor"`
+	Permission string `json:"permission"` //perm of VexAPI by default
+}
+
+//ActionInfo action of any contract, Data is json serialized by the contract ABI
+type ActionInfo struct {
+	Account       string           `json:"account"`
+	Name          string           `json:"name"`
+	Authorization []PermissionInfo `json:"authorization"`
+	Data          json.RawMessage  `json:"data"`
+	ABI           json.RawMessage  `json:"abi"` //abi of contract, or result of get_abi
+}
+
+//MaxVoteProducers producers one account can vote for
+const MaxVoteProducers = 30
+
+//VoteProducerInfo vote producers, or vote through Proxy when it is set
+type VoteProducerInfo struct {
+	Voter     string   `json:"voter"`
+	Proxy     string   `json:"proxy"`
+	Producers []string `json:"producers"`
+}
+
+//RegProxyInfo register or unregister account as voting proxy
+type RegProxyInfo struct {
+	Proxy   string `json:"proxy"`
+	IsProxy int    `json:"isproxy"`
+}
+
+//REXSymbol symbol of REX shares
+var REXSymbol = eos.Symbol{Precision: 4, Symbol: "REX"}
+
+//RefundInfo claim refund of undelegated tokens
+type RefundInfo struct {
+	Owner string `json:"owner"`
+}
+
+//REXInfo deposit, withdraw and buyrex take VEX Amount, sellrex takes REX Amount
+type REXInfo struct {
+	Owner  string `json:"owner"`
+	Amount string `json:"amount"`
+}
+
+//RentInfo rent CPU or NET for Receiver, LoanFund tops up the loan for renewal
+type RentInfo struct {
+	From        string `json:"from"`
+	Receiver    string `json:"receiver"`
+	LoanPayment string `json:"loan_payment"`
+	LoanFund    string `json:"loan_fund"`
+}
+
+//rexAmount action data of deposit and withdraw
+type rexAmount struct {
+	Owner  eos.AccountName `json:"owner"`
+	Amount eos.Asset       `json:"amount"`
+}
+
+//rexBuy action data of buyrex
+type rexBuy struct {
+	From   eos.AccountName `json:"from"`
+	Amount eos.Asset       `json:"amount"`
+}
+
+//rexSell action data of sellrex
+type rexSell struct {
+	From eos.AccountName `json:"from"`
+	REX  eos.Asset       `json:"rex"`
+}
+
+//rexRent action data of rentcpu and rentnet
+type rexRent struct {
+	From        eos.AccountName `json:"from"`
+	Receiver    eos.AccountName `json:"receiver"`
+	LoanPayment eos.Asset       `json:"loan_payment"`
+	LoanFund    eos.Asset       `json:"loan_fund"`
+}
+
+//UpdateAuthInfo create or change permission of account, Parent is not required for owner
+type UpdateAuthInfo struct {
+	Account    string        `json:"account"`
+	Permission string        `json:"permission"`
+	Parent     string        `json:"parent"`
+	Auth       AuthorityInfo `json:"auth"`
+}
+
+//DeleteAuthInfo delete custom permission of account
+type DeleteAuthInfo struct {
+	Account    string `json:"account"`
+	Permission string `json:"permission"`
+}
+
+//LinkAuthInfo require permission Requirement for action Type of contract Code, empty Type is all actions
+type LinkAuthInfo struct {
+	Account     string `json:"account"`
+	Code        string `json:"code"`
+	Type        string `json:"type"`
+	Requirement string `json:"requirement"`
+}
+
+//UnlinkAuthInfo remove link of action Type of contract Code
+type UnlinkAuthInfo struct {
+	Account string `json:"account"`
+	Code    string `json:"code"`
+	Type    string `json:"type"`
+}
+
+//VEXMsigContract contract of multisig proposals
+const VEXMsigContract = "vex.msig"
+
+//ProposeInfo propose Actions to be approved by Requested, proposal expires after ExpireHours, 24 by default
+type ProposeInfo struct {
+	Proposer     string           `json:"proposer"`
+	ProposalName string           `json:"proposal_name"`
+	Requested    []PermissionInfo `json:"requested"`
+	Actions      []ActionInfo     `json:"actions"`
+	ExpireHours  uint32           `json:"expire_hours"`
+}
+
+//ApproveInfo approve or unapprove proposal with Level
+type ApproveInfo struct {
+	Proposer     string         `json:"proposer"`
+	ProposalName string         `json:"proposal_name"`
+	Level        PermissionInfo `json:"level"`
+}
+
+//ExecInfo execute approved proposal
+type ExecInfo struct {
+	Proposer     string `json:"proposer"`
+	ProposalName string `json:"proposal_name"`
+	Executer     string `json:"executer"`
+}
+
+//CancelInfo cancel proposal, anyone can cancel an expired one
+type CancelInfo struct {
+	Proposer     string `json:"proposer"`
+	ProposalName string `json:"proposal_name"`
+	Canceler     string `json:"canceler"`
+}
+
+//msigPropose action data of propose
+type msigPropose struct {
+	Proposer     eos.AccountName       `json:"proposer"`
+	ProposalName eos.Name              `json:"proposal_name"`
+	Requested    []eos.PermissionLevel `json:"requested"`
+	Transaction  *eos.Transaction      `json:"trx"`
+}
+
+//msigApprove action data of approve and unapprove
+type msigApprove struct {
+	Proposer     eos.AccountName     `json:"proposer"`
+	ProposalName eos.Name            `json:"proposal_name"`
+	Level        eos.PermissionLevel `json:"level"`
+}
+
+//msigExec action data of exec
+type msigExec struct {
+	Proposer     eos.AccountName `json:"proposer"`
+	ProposalName eos.Name        `json:"proposal_name"`
+	Executer     eos.AccountName `json:"executer"`
+}
+
+//msigCancel action data of cancel
+type msigCancel struct {
+	Proposer     eos.AccountName `json:"proposer"`
+	ProposalName eos.Name        `json:"proposal_name"`
+	Canceler     eos.AccountName `json:"canceler"`
+}
+
+//OfflineTxInfo header of transaction built without node access, reference block is RefBlockID,
+//or RefBlockNum with RefBlockPrefix; RefBlockNum is the block number or its low 16 bits as ref_block_num
+//of a transaction header; Expiration is UTC like "2006-01-02T15:04:05", 30 seconds later by default
+type OfflineTxInfo struct {
+	ChainID        string `json:"chain_id"`
+	RefBlockID     string `json:"ref_block_id"`
+	RefBlockNum    uint32 `json:"ref_block_num"`
+	RefBlockPrefix uint32 `json:"ref_block_prefix"`
+	Expiration     string `json:"expiration"`
+	DelaySec       uint32 `json:"delay_sec"`
+}